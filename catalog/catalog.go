@@ -0,0 +1,817 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Catalog is an in-memory model of the database schema, built by replaying the migration statements in order.
+// Objects are identified by their name without the schema qualifier.
+type Catalog struct {
+	tables  map[string]*Table
+	indexes map[string]*Index
+	views   map[string]*View
+	types   map[string]*Type
+}
+
+// Table is a table of the schema.
+type Table struct {
+	Name        string
+	Columns     []*Column
+	Constraints []*Constraint
+	Temporary   bool
+	Partitioned bool
+	// PartitionOf is the name of the parent table, when the table is a partition.
+	PartitionOf string
+}
+
+// Column is a table column.
+type Column struct {
+	Name       string
+	Type       *pg_query.TypeName
+	NotNull    bool
+	HasDefault bool
+}
+
+// ConstraintType is the type of a table constraint.
+type ConstraintType string
+
+const (
+	PrimaryKey ConstraintType = "primary key"
+	Unique     ConstraintType = "unique"
+	ForeignKey ConstraintType = "foreign key"
+	Check      ConstraintType = "check"
+	Exclusion  ConstraintType = "exclusion"
+)
+
+// Constraint is a table constraint. Constraints without an explicit name are assigned the default PostgreSQL name.
+type Constraint struct {
+	Name     string
+	Type     ConstraintType
+	Columns  []string
+	RefTable string
+	// NotValid is set for constraints added with NOT VALID that have not been validated.
+	NotValid bool
+}
+
+// Index is an index of a table or materialized view.
+type Index struct {
+	Name  string
+	Table string
+	// Columns contains the indexed column names, with an empty name for expressions.
+	Columns []string
+	Unique  bool
+	Partial bool
+	// Constraint is set for the indexes that implement PRIMARY KEY and UNIQUE constraints.
+	Constraint bool
+}
+
+// View is a view or materialized view.
+type View struct {
+	Name         string
+	Materialized bool
+	Query        *pg_query.Node
+}
+
+// Type is a user-defined type. Values are only set for enum types.
+type Type struct {
+	Name   string
+	Values []string
+}
+
+// New returns an empty catalog.
+func New() *Catalog {
+	return &Catalog{
+		tables:  make(map[string]*Table),
+		indexes: make(map[string]*Index),
+		views:   make(map[string]*View),
+		types:   make(map[string]*Type),
+	}
+}
+
+// Clone returns a deep copy of the catalog.
+func (c *Catalog) Clone() *Catalog {
+	clone := New()
+	for name, t := range c.tables {
+		table := *t
+		table.Columns = nil
+		for _, column := range t.Columns {
+			col := *column
+			table.Columns = append(table.Columns, &col)
+		}
+		table.Constraints = nil
+		for _, constraint := range t.Constraints {
+			con := *constraint
+			con.Columns = append([]string{}, constraint.Columns...)
+			table.Constraints = append(table.Constraints, &con)
+		}
+		clone.tables[name] = &table
+	}
+	for name, i := range c.indexes {
+		index := *i
+		index.Columns = append([]string{}, i.Columns...)
+		clone.indexes[name] = &index
+	}
+	for name, v := range c.views {
+		view := *v
+		clone.views[name] = &view
+	}
+	for name, t := range c.types {
+		typ := *t
+		typ.Values = append([]string{}, t.Values...)
+		clone.types[name] = &typ
+	}
+	return clone
+}
+
+// Table returns the table with the given name.
+func (c *Catalog) Table(name string) (*Table, bool) {
+	t, ok := c.tables[name]
+	return t, ok
+}
+
+// Tables returns all the tables, sorted by name.
+func (c *Catalog) Tables() []*Table {
+	var tables []*Table
+	for _, name := range sortedKeys(c.tables) {
+		tables = append(tables, c.tables[name])
+	}
+	return tables
+}
+
+// Index returns the index with the given name.
+func (c *Catalog) Index(name string) (*Index, bool) {
+	i, ok := c.indexes[name]
+	return i, ok
+}
+
+// Indexes returns the indexes of the table or materialized view, sorted by name.
+func (c *Catalog) Indexes(table string) []*Index {
+	var indexes []*Index
+	for _, name := range sortedKeys(c.indexes) {
+		if c.indexes[name].Table == table {
+			indexes = append(indexes, c.indexes[name])
+		}
+	}
+	return indexes
+}
+
+// View returns the view or materialized view with the given name.
+func (c *Catalog) View(name string) (*View, bool) {
+	v, ok := c.views[name]
+	return v, ok
+}
+
+// Views returns all the views and materialized views, sorted by name.
+func (c *Catalog) Views() []*View {
+	var views []*View
+	for _, name := range sortedKeys(c.views) {
+		views = append(views, c.views[name])
+	}
+	return views
+}
+
+// Type returns the user-defined type with the given name.
+func (c *Catalog) Type(name string) (*Type, bool) {
+	t, ok := c.types[name]
+	return t, ok
+}
+
+// Column returns the column with the given name.
+func (t *Table) Column(name string) (*Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return nil, false
+}
+
+// PrimaryKey returns the primary key constraint of the table.
+func (t *Table) PrimaryKey() (*Constraint, bool) {
+	for _, constraint := range t.Constraints {
+		if constraint.Type == PrimaryKey {
+			return constraint, true
+		}
+	}
+	return nil, false
+}
+
+// Apply updates the catalog with the changes of the statement.
+// Statements that do not change the schema, or are not supported, are ignored.
+func (c *Catalog) Apply(node *pg_query.Node) {
+	switch {
+	case node.GetCreateStmt() != nil:
+		c.createTable(node.GetCreateStmt())
+	case node.GetAlterTableStmt() != nil:
+		c.alterTable(node.GetAlterTableStmt())
+	case node.GetIndexStmt() != nil:
+		c.createIndex(node.GetIndexStmt())
+	case node.GetViewStmt() != nil:
+		name := node.GetViewStmt().GetView().GetRelname()
+		c.views[name] = &View{Name: name, Query: node.GetViewStmt().GetQuery()}
+	case node.GetCreateTableAsStmt() != nil:
+		c.createTableAs(node.GetCreateTableAsStmt())
+	case node.GetCreateEnumStmt() != nil:
+		name := lastName(node.GetCreateEnumStmt().GetTypeName())
+		c.types[name] = &Type{Name: name, Values: stringValues(node.GetCreateEnumStmt().GetVals())}
+	case node.GetCompositeTypeStmt() != nil:
+		name := node.GetCompositeTypeStmt().GetTypevar().GetRelname()
+		c.types[name] = &Type{Name: name}
+	case node.GetCreateDomainStmt() != nil:
+		name := lastName(node.GetCreateDomainStmt().GetDomainname())
+		c.types[name] = &Type{Name: name}
+	case node.GetAlterEnumStmt() != nil:
+		c.alterEnum(node.GetAlterEnumStmt())
+	case node.GetDropStmt() != nil:
+		c.drop(node.GetDropStmt())
+	case node.GetRenameStmt() != nil:
+		c.rename(node.GetRenameStmt())
+	}
+}
+
+func (c *Catalog) createTable(createStmt *pg_query.CreateStmt) {
+	name := createStmt.GetRelation().GetRelname()
+	if _, ok := c.tables[name]; ok && createStmt.GetIfNotExists() {
+		return
+	}
+	c.dropTable(name)
+	table := &Table{
+		Name:        name,
+		Temporary:   createStmt.GetRelation().GetRelpersistence() == "t",
+		Partitioned: createStmt.GetPartspec() != nil,
+	}
+	if createStmt.GetPartbound() != nil && len(createStmt.GetInhRelations()) > 0 {
+		table.PartitionOf = createStmt.GetInhRelations()[0].GetRangeVar().GetRelname()
+	}
+	c.tables[name] = table
+	for _, elt := range createStmt.GetTableElts() {
+		switch {
+		case elt.GetColumnDef() != nil:
+			c.addColumn(table, elt.GetColumnDef())
+		case elt.GetTableLikeClause() != nil:
+			if source, ok := c.tables[elt.GetTableLikeClause().GetRelation().GetRelname()]; ok {
+				for _, column := range source.Columns {
+					col := *column
+					table.Columns = append(table.Columns, &col)
+				}
+			}
+		}
+	}
+	// Table constraints can be defined before the columns they refer to
+	for _, elt := range createStmt.GetTableElts() {
+		if constraint := elt.GetConstraint(); constraint != nil {
+			c.addConstraint(table, constraint, nil)
+		}
+	}
+}
+
+func (c *Catalog) createTableAs(createStmt *pg_query.CreateTableAsStmt) {
+	name := createStmt.GetInto().GetRel().GetRelname()
+	if createStmt.GetObjtype() == pg_query.ObjectType_OBJECT_MATVIEW {
+		if _, ok := c.views[name]; ok && createStmt.GetIfNotExists() {
+			return
+		}
+		c.dropIndexes(name)
+		c.views[name] = &View{Name: name, Materialized: true, Query: createStmt.GetQuery()}
+		return
+	}
+	if _, ok := c.tables[name]; ok && createStmt.GetIfNotExists() {
+		return
+	}
+	c.dropTable(name)
+	// The columns are defined by the query
+	table := &Table{Name: name}
+	for _, colName := range createStmt.GetInto().GetColNames() {
+		table.Columns = append(table.Columns, &Column{Name: colName.GetString_().GetSval()})
+	}
+	c.tables[name] = table
+}
+
+func (c *Catalog) addColumn(table *Table, columnDef *pg_query.ColumnDef) {
+	column := &Column{Name: columnDef.GetColname(), Type: columnDef.GetTypeName()}
+	table.Columns = append(table.Columns, column)
+	for _, constraint := range columnDef.GetConstraints() {
+		switch constraint.GetConstraint().GetContype() {
+		case pg_query.ConstrType_CONSTR_NOTNULL:
+			column.NotNull = true
+		case pg_query.ConstrType_CONSTR_DEFAULT, pg_query.ConstrType_CONSTR_IDENTITY, pg_query.ConstrType_CONSTR_GENERATED:
+			column.HasDefault = true
+		default:
+			c.addConstraint(table, constraint.GetConstraint(), []string{column.Name})
+		}
+	}
+	switch strings.ToLower(lastName(columnDef.GetTypeName().GetNames())) {
+	case "smallserial", "serial", "bigserial", "serial2", "serial4", "serial8":
+		column.NotNull, column.HasDefault = true, true
+	}
+}
+
+// addConstraint adds a table constraint. The columns of column constraints are given, since they are not part of the constraint definition.
+func (c *Catalog) addConstraint(table *Table, definition *pg_query.Constraint, columns []string) {
+	constraint := &Constraint{Name: definition.GetConname(), Columns: columns, NotValid: definition.GetSkipValidation()}
+	switch definition.GetContype() {
+	case pg_query.ConstrType_CONSTR_PRIMARY:
+		constraint.Type = PrimaryKey
+	case pg_query.ConstrType_CONSTR_UNIQUE:
+		constraint.Type = Unique
+	case pg_query.ConstrType_CONSTR_FOREIGN:
+		constraint.Type = ForeignKey
+		constraint.RefTable = definition.GetPktable().GetRelname()
+		if len(definition.GetFkAttrs()) > 0 {
+			constraint.Columns = stringValues(definition.GetFkAttrs())
+		}
+	case pg_query.ConstrType_CONSTR_CHECK:
+		constraint.Type = Check
+	case pg_query.ConstrType_CONSTR_EXCLUSION:
+		constraint.Type = Exclusion
+		// Each exclusion element is a list of the index element and the operator
+		for _, exclusion := range definition.GetExclusions() {
+			if items := exclusion.GetList().GetItems(); len(items) > 0 {
+				constraint.Columns = append(constraint.Columns, items[0].GetIndexElem().GetName())
+			}
+		}
+	default:
+		return
+	}
+	if len(definition.GetKeys()) > 0 {
+		constraint.Columns = stringValues(definition.GetKeys())
+	}
+	// Constraints that use an existing index take over its columns and name
+	if indexName := definition.GetIndexname(); indexName != "" {
+		if index, ok := c.indexes[indexName]; ok {
+			constraint.Columns = index.Columns
+			if constraint.Name == "" {
+				constraint.Name = indexName
+			}
+			delete(c.indexes, indexName)
+		}
+	}
+	if constraint.Name == "" {
+		constraint.Name = c.defaultConstraintName(table.Name, constraint, definition)
+	}
+	if constraint.Type == PrimaryKey {
+		for _, column := range table.Columns {
+			for _, key := range constraint.Columns {
+				if column.Name == key {
+					column.NotNull = true
+				}
+			}
+		}
+	}
+	table.Constraints = append(table.Constraints, constraint)
+	if constraint.Type == PrimaryKey || constraint.Type == Unique || constraint.Type == Exclusion {
+		c.indexes[constraint.Name] = &Index{
+			Name:       constraint.Name,
+			Table:      table.Name,
+			Columns:    constraint.Columns,
+			Unique:     constraint.Type != Exclusion,
+			Constraint: true,
+		}
+	}
+}
+
+// defaultConstraintName returns the name that PostgreSQL assigns to a constraint defined without a name,
+// following ChooseConstraintName: check constraints are named after the column of their expression, when there is a single one.
+func (c *Catalog) defaultConstraintName(table string, constraint *Constraint, definition *pg_query.Constraint) string {
+	switch constraint.Type {
+	case PrimaryKey:
+		return chooseName(table, "", "pkey", c.indexNameUsed)
+	case Unique:
+		return chooseName(table, nameAddition(constraint.Columns), "key", c.indexNameUsed)
+	case Exclusion:
+		return chooseName(table, nameAddition(constraint.Columns), "excl", c.indexNameUsed)
+	case ForeignKey:
+		return chooseName(table, nameAddition(constraint.Columns), "fkey", c.constraintNameUsed)
+	default:
+		var column string
+		if columns := referencedColumns(definition.GetRawExpr()); len(columns) == 1 {
+			column = columns[0]
+		}
+		return chooseName(table, column, "check", c.constraintNameUsed)
+	}
+}
+
+// indexNameUsed reports whether the name cannot be assigned to an index implementing a constraint,
+// as it is used by a relation or a constraint.
+func (c *Catalog) indexNameUsed(name string) bool {
+	return c.relationNameUsed(name) || c.constraintNameUsed(name)
+}
+
+func (c *Catalog) relationNameUsed(name string) bool {
+	_, table := c.tables[name]
+	_, index := c.indexes[name]
+	_, view := c.views[name]
+	return table || index || view
+}
+
+func (c *Catalog) constraintNameUsed(name string) bool {
+	for _, table := range c.tables {
+		for _, constraint := range table.Constraints {
+			if constraint.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxIdentifierLength is the maximum length in bytes of PostgreSQL identifiers, i.e. NAMEDATALEN - 1.
+const maxIdentifierLength = 63
+
+// chooseName returns the first name built from the parts that is not used, appending 1, 2, ... to the label on collisions.
+func chooseName(name1, name2, label string, used func(name string) bool) string {
+	name := makeObjectName(name1, name2, label)
+	for pass := 1; used(name); pass++ {
+		name = makeObjectName(name1, name2, fmt.Sprintf("%s%d", label, pass))
+	}
+	return name
+}
+
+// makeObjectName joins the parts with underscores, omitting an empty name2. Like PostgreSQL, the longer of
+// name1 and name2 is truncated first so that the name fits in an identifier, while the label is kept.
+func makeObjectName(name1, name2, label string) string {
+	length1, length2 := len(name1), len(name2)
+	available := maxIdentifierLength - len(label) - 1
+	if name2 != "" {
+		available--
+	}
+	for length1+length2 > available {
+		if length1 > length2 {
+			length1--
+		} else {
+			length2--
+		}
+	}
+	name := clip(name1, length1)
+	if name2 != "" {
+		name += "_" + clip(name2, length2)
+	}
+	return name + "_" + label
+}
+
+// clip truncates the name to at most length bytes, without splitting a multibyte character.
+func clip(name string, length int) string {
+	for length > 0 && length < len(name) && !utf8.RuneStart(name[length]) {
+		length--
+	}
+	return name[:length]
+}
+
+// nameAddition joins the column names with underscores, stopping once the result exceeds an identifier.
+// Expressions, i.e. empty column names, are named expr.
+func nameAddition(columns []string) string {
+	var addition string
+	for _, column := range columns {
+		if addition != "" {
+			addition += "_"
+		}
+		if column == "" {
+			column = "expr"
+		}
+		addition += column
+		if len(addition) > maxIdentifierLength {
+			break
+		}
+	}
+	return addition
+}
+
+// referencedColumns returns the distinct names of the columns referenced in the expression.
+func referencedColumns(expr *pg_query.Node) []string {
+	var columns []string
+	var visit func(m protoreflect.Message)
+	visit = func(m protoreflect.Message) {
+		if node, ok := m.Interface().(*pg_query.Node); ok && node.GetColumnRef() != nil {
+			if name := lastName(node.GetColumnRef().GetFields()); name != "" && !contains(columns, name) {
+				columns = append(columns, name)
+			}
+			return
+		}
+		m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			switch {
+			case fd.Kind() != protoreflect.MessageKind || fd.IsMap():
+			case fd.IsList():
+				for i := 0; i < v.List().Len(); i++ {
+					visit(v.List().Get(i).Message())
+				}
+			default:
+				visit(v.Message())
+			}
+			return true
+		})
+	}
+	if expr != nil {
+		visit(expr.ProtoReflect())
+	}
+	return columns
+}
+
+func (c *Catalog) alterTable(alterTableStmt *pg_query.AlterTableStmt) {
+	table, ok := c.tables[alterTableStmt.GetRelation().GetRelname()]
+	if !ok {
+		return
+	}
+	for _, cmd := range alterTableStmt.GetCmds() {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		switch alterTableCmd.GetSubtype() {
+		case pg_query.AlterTableType_AT_AddColumn:
+			columnDef := alterTableCmd.GetDef().GetColumnDef()
+			if _, exists := table.Column(columnDef.GetColname()); exists && alterTableCmd.GetMissingOk() {
+				continue
+			}
+			c.addColumn(table, columnDef)
+		case pg_query.AlterTableType_AT_DropColumn:
+			c.dropColumn(table, alterTableCmd.GetName())
+		case pg_query.AlterTableType_AT_AlterColumnType:
+			if column, ok := table.Column(alterTableCmd.GetName()); ok {
+				column.Type = alterTableCmd.GetDef().GetColumnDef().GetTypeName()
+			}
+		case pg_query.AlterTableType_AT_SetNotNull, pg_query.AlterTableType_AT_DropNotNull:
+			if column, ok := table.Column(alterTableCmd.GetName()); ok {
+				column.NotNull = alterTableCmd.GetSubtype() == pg_query.AlterTableType_AT_SetNotNull
+			}
+		case pg_query.AlterTableType_AT_ColumnDefault:
+			if column, ok := table.Column(alterTableCmd.GetName()); ok {
+				column.HasDefault = alterTableCmd.GetDef() != nil
+			}
+		case pg_query.AlterTableType_AT_AddIdentity:
+			if column, ok := table.Column(alterTableCmd.GetName()); ok {
+				column.NotNull, column.HasDefault = true, true
+			}
+		case pg_query.AlterTableType_AT_AttachPartition:
+			if partition, ok := c.tables[alterTableCmd.GetDef().GetPartitionCmd().GetName().GetRelname()]; ok {
+				partition.PartitionOf = table.Name
+			}
+		case pg_query.AlterTableType_AT_DetachPartition:
+			if partition, ok := c.tables[alterTableCmd.GetDef().GetPartitionCmd().GetName().GetRelname()]; ok {
+				partition.PartitionOf = ""
+			}
+		case pg_query.AlterTableType_AT_AddConstraint:
+			c.addConstraint(table, alterTableCmd.GetDef().GetConstraint(), nil)
+		case pg_query.AlterTableType_AT_ValidateConstraint:
+			for _, constraint := range table.Constraints {
+				if constraint.Name == alterTableCmd.GetName() {
+					constraint.NotValid = false
+				}
+			}
+		case pg_query.AlterTableType_AT_DropConstraint:
+			c.dropConstraint(table, alterTableCmd.GetName())
+		}
+	}
+}
+
+func (c *Catalog) dropColumn(table *Table, name string) {
+	var columns []*Column
+	for _, column := range table.Columns {
+		if column.Name != name {
+			columns = append(columns, column)
+		}
+	}
+	table.Columns = columns
+	// Indexes and constraints that include the column are dropped along with it
+	for _, constraint := range table.Constraints {
+		if contains(constraint.Columns, name) {
+			c.dropConstraint(table, constraint.Name)
+		}
+	}
+	for indexName, index := range c.indexes {
+		if index.Table == table.Name && contains(index.Columns, name) {
+			delete(c.indexes, indexName)
+		}
+	}
+}
+
+func (c *Catalog) dropConstraint(table *Table, name string) {
+	var constraints []*Constraint
+	for _, constraint := range table.Constraints {
+		if constraint.Name != name {
+			constraints = append(constraints, constraint)
+		}
+	}
+	table.Constraints = constraints
+	if index, ok := c.indexes[name]; ok && index.Constraint {
+		delete(c.indexes, name)
+	}
+}
+
+func (c *Catalog) createIndex(indexStmt *pg_query.IndexStmt) {
+	index := &Index{
+		Name:    indexStmt.GetIdxname(),
+		Table:   indexStmt.GetRelation().GetRelname(),
+		Unique:  indexStmt.GetUnique(),
+		Partial: indexStmt.GetWhereClause() != nil,
+	}
+	for _, param := range indexStmt.GetIndexParams() {
+		index.Columns = append(index.Columns, param.GetIndexElem().GetName())
+	}
+	if index.Name == "" {
+		index.Name = c.defaultIndexName(index)
+	}
+	if _, ok := c.indexes[index.Name]; ok && indexStmt.GetIfNotExists() {
+		return
+	}
+	c.indexes[index.Name] = index
+}
+
+// defaultIndexName returns the name that PostgreSQL assigns to an index created without a name.
+func (c *Catalog) defaultIndexName(index *Index) string {
+	return chooseName(index.Table, nameAddition(index.Columns), "idx", c.relationNameUsed)
+}
+
+func (c *Catalog) alterEnum(alterEnumStmt *pg_query.AlterEnumStmt) {
+	typ, ok := c.types[lastName(alterEnumStmt.GetTypeName())]
+	if !ok {
+		return
+	}
+	if oldValue := alterEnumStmt.GetOldVal(); oldValue != "" {
+		for i, value := range typ.Values {
+			if value == oldValue {
+				typ.Values[i] = alterEnumStmt.GetNewVal()
+			}
+		}
+		return
+	}
+	if !contains(typ.Values, alterEnumStmt.GetNewVal()) {
+		typ.Values = append(typ.Values, alterEnumStmt.GetNewVal())
+	}
+}
+
+func (c *Catalog) drop(dropStmt *pg_query.DropStmt) {
+	for _, object := range dropStmt.GetObjects() {
+		switch dropStmt.GetRemoveType() {
+		case pg_query.ObjectType_OBJECT_TABLE:
+			c.dropTable(lastName(object.GetList().GetItems()))
+		case pg_query.ObjectType_OBJECT_INDEX:
+			delete(c.indexes, lastName(object.GetList().GetItems()))
+		case pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+			name := lastName(object.GetList().GetItems())
+			delete(c.views, name)
+			c.dropIndexes(name)
+		case pg_query.ObjectType_OBJECT_TYPE, pg_query.ObjectType_OBJECT_DOMAIN:
+			delete(c.types, lastName(object.GetTypeName().GetNames()))
+		}
+	}
+}
+
+func (c *Catalog) dropTable(name string) {
+	delete(c.tables, name)
+	c.dropIndexes(name)
+}
+
+func (c *Catalog) dropIndexes(table string) {
+	for name, index := range c.indexes {
+		if index.Table == table {
+			delete(c.indexes, name)
+		}
+	}
+}
+
+func (c *Catalog) rename(renameStmt *pg_query.RenameStmt) {
+	newName := renameStmt.GetNewname()
+	switch renameStmt.GetRenameType() {
+	case pg_query.ObjectType_OBJECT_TABLE:
+		oldName := renameStmt.GetRelation().GetRelname()
+		table, ok := c.tables[oldName]
+		if !ok {
+			return
+		}
+		delete(c.tables, oldName)
+		table.Name = newName
+		c.tables[newName] = table
+		for _, index := range c.indexes {
+			if index.Table == oldName {
+				index.Table = newName
+			}
+		}
+		for _, t := range c.tables {
+			for _, constraint := range t.Constraints {
+				if constraint.RefTable == oldName {
+					constraint.RefTable = newName
+				}
+			}
+		}
+	case pg_query.ObjectType_OBJECT_COLUMN:
+		table, ok := c.tables[renameStmt.GetRelation().GetRelname()]
+		if !ok {
+			return
+		}
+		oldName := renameStmt.GetSubname()
+		if column, ok := table.Column(oldName); ok {
+			column.Name = newName
+		}
+		for _, constraint := range table.Constraints {
+			replace(constraint.Columns, oldName, newName)
+		}
+		for _, index := range c.indexes {
+			if index.Table == table.Name {
+				replace(index.Columns, oldName, newName)
+			}
+		}
+	case pg_query.ObjectType_OBJECT_INDEX:
+		c.renameIndex(renameStmt.GetRelation().GetRelname(), newName)
+	case pg_query.ObjectType_OBJECT_TABCONSTRAINT:
+		table, ok := c.tables[renameStmt.GetRelation().GetRelname()]
+		if !ok {
+			return
+		}
+		for _, constraint := range table.Constraints {
+			if constraint.Name == renameStmt.GetSubname() {
+				constraint.Name = newName
+			}
+		}
+		if index, ok := c.indexes[renameStmt.GetSubname()]; ok && index.Constraint {
+			c.renameIndex(index.Name, newName)
+		}
+	case pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+		oldName := renameStmt.GetRelation().GetRelname()
+		view, ok := c.views[oldName]
+		if !ok {
+			return
+		}
+		delete(c.views, oldName)
+		view.Name = newName
+		c.views[newName] = view
+		for _, index := range c.indexes {
+			if index.Table == oldName {
+				index.Table = newName
+			}
+		}
+	case pg_query.ObjectType_OBJECT_TYPE:
+		oldName := lastName(renameStmt.GetObject().GetList().GetItems())
+		typ, ok := c.types[oldName]
+		if !ok {
+			return
+		}
+		delete(c.types, oldName)
+		typ.Name = newName
+		c.types[newName] = typ
+	}
+}
+
+// renameIndex renames an index, along with the constraint it implements.
+func (c *Catalog) renameIndex(oldName, newName string) {
+	index, ok := c.indexes[oldName]
+	if !ok {
+		return
+	}
+	delete(c.indexes, oldName)
+	index.Name = newName
+	c.indexes[newName] = index
+	if !index.Constraint {
+		return
+	}
+	if table, ok := c.tables[index.Table]; ok {
+		for _, constraint := range table.Constraints {
+			if constraint.Name == oldName {
+				constraint.Name = newName
+			}
+		}
+	}
+}
+
+func lastName(nodes []*pg_query.Node) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	return nodes[len(nodes)-1].GetString_().GetSval()
+}
+
+func stringValues(nodes []*pg_query.Node) []string {
+	var values []string
+	for _, n := range nodes {
+		values = append(values, n.GetString_().GetSval())
+	}
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func replace(values []string, oldValue, newValue string) {
+	for i, v := range values {
+		if v == oldValue {
+			values[i] = newValue
+		}
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,244 @@
+package catalog
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func newCatalog(t *testing.T, sql string) *Catalog {
+	t.Helper()
+
+	c := New()
+	for _, statement := range strings.Split(sql, "\n") {
+		tree, err := pg_query.Parse(statement)
+		require.NoError(t, err)
+		for _, s := range tree.GetStmts() {
+			c.Apply(s.GetStmt())
+		}
+	}
+	return c
+}
+
+func columnNames(table *Table) []string {
+	var names []string
+	for _, column := range table.Columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+func indexNames(indexes []*Index) []string {
+	var names []string
+	for _, index := range indexes {
+		names = append(names, index.Name)
+	}
+	return names
+}
+
+func TestCatalog_Tables(t *testing.T) {
+	t.Parallel()
+
+	c := newCatalog(t, `CREATE TABLE movies (id bigint PRIMARY KEY, title varchar(100) NOT NULL, rating integer DEFAULT 0);
+ALTER TABLE movies ADD COLUMN released_at timestamptz, DROP COLUMN rating;
+ALTER TABLE movies ALTER COLUMN title TYPE text;
+ALTER TABLE movies RENAME COLUMN released_at TO release_date;
+CREATE TABLE directors (id serial, name text);
+DROP TABLE directors;
+CREATE TABLE measurements (id bigint, logged_at timestamptz) PARTITION BY RANGE (logged_at);
+CREATE TABLE measurements_2023 PARTITION OF measurements FOR VALUES FROM ('2023-01-01') TO ('2024-01-01');`)
+
+	movies, ok := c.Table("movies")
+	require.True(t, ok)
+	assert.Equal(t, []string{"id", "title", "release_date"}, columnNames(movies))
+	title, ok := movies.Column("title")
+	require.True(t, ok)
+	assert.True(t, title.NotNull)
+	assert.Equal(t, "text", title.Type.GetNames()[len(title.Type.GetNames())-1].GetString_().GetSval())
+	id, _ := movies.Column("id")
+	assert.True(t, id.NotNull)
+
+	_, ok = c.Table("directors")
+	assert.False(t, ok)
+
+	measurements, _ := c.Table("measurements")
+	assert.True(t, measurements.Partitioned)
+	partition, _ := c.Table("measurements_2023")
+	assert.Equal(t, "measurements", partition.PartitionOf)
+
+	var names []string
+	for _, table := range c.Tables() {
+		names = append(names, table.Name)
+	}
+	assert.Equal(t, []string{"measurements", "measurements_2023", "movies"}, names)
+}
+
+func TestCatalog_Constraints(t *testing.T) {
+	t.Parallel()
+
+	c := newCatalog(t, `CREATE TABLE ratings (id bigint, movie_id bigint REFERENCES movies (id), score integer CHECK (score > 0), PRIMARY KEY (id));
+ALTER TABLE ratings ADD CONSTRAINT ratings_score_unique UNIQUE (movie_id, score);
+ALTER TABLE ratings ADD CONSTRAINT ratings_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;
+ALTER TABLE ratings DROP CONSTRAINT ratings_score_check;`)
+
+	ratings, ok := c.Table("ratings")
+	require.True(t, ok)
+	var constraints []string
+	for _, constraint := range ratings.Constraints {
+		constraints = append(constraints, string(constraint.Type)+" "+constraint.Name)
+	}
+	assert.Equal(t, []string{
+		"foreign key ratings_movie_id_fkey",
+		"primary key ratings_pkey",
+		"unique ratings_score_unique",
+		"foreign key ratings_user_fk",
+	}, constraints)
+	pk, ok := ratings.PrimaryKey()
+	require.True(t, ok)
+	assert.Equal(t, []string{"id"}, pk.Columns)
+	assert.True(t, ratings.Constraints[3].NotValid)
+	assert.Equal(t, "users", ratings.Constraints[3].RefTable)
+	assert.Equal(t, []string{"ratings_pkey", "ratings_score_unique"}, indexNames(c.Indexes("ratings")))
+}
+
+func TestCatalog_DefaultNames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		sql   string
+		table string
+		want  []string
+	}{
+		{
+			name: "check constraints named after their single column",
+			sql: `CREATE TABLE movies (id bigint, rating integer CHECK (rating > 0), budget integer, revenue integer);
+ALTER TABLE movies ADD CHECK (budget > 0 AND budget < 1000000000);
+ALTER TABLE movies ADD CHECK (revenue > budget);`,
+			table: "movies",
+			want:  []string{"movies_rating_check", "movies_budget_check", "movies_check"},
+		},
+		{
+			name: "numeric suffix on constraint name collisions",
+			sql: `CREATE TABLE movies (id bigint, rating integer);
+ALTER TABLE movies ADD CHECK (rating > 0);
+ALTER TABLE movies ADD CHECK (rating < 10);
+CREATE TABLE reviews (id bigint, rating integer);
+ALTER TABLE reviews ADD CONSTRAINT movies_rating_check2 CHECK (rating > 0);
+ALTER TABLE movies ADD CHECK (rating <> 5);`,
+			table: "movies",
+			want:  []string{"movies_rating_check", "movies_rating_check1", "movies_rating_check3"},
+		},
+		{
+			name: "numeric suffix on relation name collisions",
+			sql: `CREATE TABLE movies (id bigint, title text);
+CREATE INDEX movies_title_key ON movies (title);
+CREATE TABLE movies_pkey (id bigint);
+ALTER TABLE movies ADD PRIMARY KEY (id), ADD UNIQUE (title);`,
+			table: "movies",
+			want:  []string{"movies_pkey1", "movies_title_key1"},
+		},
+		{
+			name: "truncated to the identifier length",
+			sql: `CREATE TABLE movie_ratings_by_critics_and_audiences_aggregated_per_year (critic_score integer);
+ALTER TABLE movie_ratings_by_critics_and_audiences_aggregated_per_year ADD CHECK (critic_score > 0);`,
+			table: "movie_ratings_by_critics_and_audiences_aggregated_per_year",
+			want:  []string{"movie_ratings_by_critics_and_audiences_aggre_critic_score_check"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			table, ok := newCatalog(t, tt.sql).Table(tt.table)
+			require.True(t, ok)
+			var names []string
+			for _, constraint := range table.Constraints {
+				names = append(names, constraint.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestCatalog_Indexes(t *testing.T) {
+	t.Parallel()
+
+	c := newCatalog(t, `CREATE TABLE movies (id bigint PRIMARY KEY, title text, year integer);
+CREATE INDEX movies_title_idx ON movies (title);
+CREATE UNIQUE INDEX ON movies (lower(title), year) WHERE year > 2000;
+CREATE INDEX IF NOT EXISTS movies_title_idx ON movies (year);
+CREATE INDEX movies_year_idx ON movies (year);
+DROP INDEX movies_year_idx;
+ALTER INDEX movies_title_idx RENAME TO movies_title_index;
+ALTER TABLE movies RENAME TO films;
+CREATE INDEX ON films (year);
+CREATE INDEX ON films (year);`)
+
+	assert.Empty(t, c.Indexes("movies"))
+	assert.Equal(t, []string{"films_year_idx", "films_year_idx1", "movies_expr_year_idx", "movies_pkey", "movies_title_index"}, indexNames(c.Indexes("films")))
+	index, ok := c.Index("movies_expr_year_idx")
+	require.True(t, ok)
+	assert.Equal(t, []string{"", "year"}, index.Columns)
+	assert.True(t, index.Unique)
+	assert.True(t, index.Partial)
+	index, _ = c.Index("movies_title_index")
+	assert.Equal(t, []string{"title"}, index.Columns)
+
+	c.Apply(parse(t, `ALTER TABLE films DROP COLUMN title;`))
+	assert.Equal(t, []string{"films_year_idx", "films_year_idx1", "movies_expr_year_idx", "movies_pkey"}, indexNames(c.Indexes("films")))
+}
+
+func TestCatalog_ViewsAndTypes(t *testing.T) {
+	t.Parallel()
+
+	c := newCatalog(t, `CREATE MATERIALIZED VIEW movie_ratings AS SELECT movie_id, avg(score) FROM ratings GROUP BY movie_id;
+CREATE UNIQUE INDEX movie_ratings_movie_id_idx ON movie_ratings (movie_id);
+CREATE VIEW recent_movies AS SELECT * FROM movies;
+DROP VIEW recent_movies;
+CREATE TYPE mood AS ENUM ('sad', 'happy');
+ALTER TYPE mood ADD VALUE 'neutral';
+ALTER TYPE mood RENAME VALUE 'sad' TO 'unhappy';`)
+
+	view, ok := c.View("movie_ratings")
+	require.True(t, ok)
+	assert.True(t, view.Materialized)
+	assert.NotNil(t, view.Query.GetSelectStmt())
+	assert.Equal(t, []string{"movie_ratings_movie_id_idx"}, indexNames(c.Indexes("movie_ratings")))
+	_, ok = c.View("recent_movies")
+	assert.False(t, ok)
+
+	mood, ok := c.Type("mood")
+	require.True(t, ok)
+	assert.Equal(t, []string{"unhappy", "happy", "neutral"}, mood.Values)
+
+	c.Apply(parse(t, `DROP MATERIALIZED VIEW movie_ratings;`))
+	assert.Empty(t, c.Views())
+	assert.Empty(t, c.Indexes("movie_ratings"))
+}
+
+func TestCatalog_Clone(t *testing.T) {
+	t.Parallel()
+
+	c := newCatalog(t, `CREATE TABLE movies (id bigint PRIMARY KEY, title text);`)
+	clone := c.Clone()
+	clone.Apply(parse(t, `ALTER TABLE movies RENAME COLUMN title TO name;`))
+	clone.Apply(parse(t, `CREATE INDEX movies_name_idx ON movies (name);`))
+
+	movies, _ := c.Table("movies")
+	assert.Equal(t, []string{"id", "title"}, columnNames(movies))
+	assert.Equal(t, []string{"movies_pkey"}, indexNames(c.Indexes("movies")))
+	movies, _ = clone.Table("movies")
+	assert.Equal(t, []string{"id", "name"}, columnNames(movies))
+}
+
+func parse(t *testing.T, sql string) *pg_query.Node {
+	t.Helper()
+
+	tree, err := pg_query.Parse(sql)
+	require.NoError(t, err)
+	return tree.GetStmts()[0].GetStmt()
+}
@@ -0,0 +1,273 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// Diff returns the differences between two schemas, one per line, in the form "<object>: <change>".
+// Objects are added when they only exist in the second schema, and removed when they only exist in the first one.
+func Diff(before, after *Catalog) []string {
+	var diff []string
+	added := func(object string) { diff = append(diff, object+": added") }
+	removed := func(object string) { diff = append(diff, object+": removed") }
+	changed := func(object, property, from, to string) {
+		if from != to {
+			diff = append(diff, fmt.Sprintf("%s: %s changed from %s to %s", object, property, from, to))
+		}
+	}
+
+	for _, name := range unionKeys(before.tables, after.tables) {
+		object := "table " + name
+		b, inBefore := before.tables[name]
+		a, inAfter := after.tables[name]
+		switch {
+		case !inBefore:
+			added(object)
+		case !inAfter:
+			removed(object)
+		default:
+			diff = append(diff, diffTables(b, a)...)
+		}
+	}
+	// The indexes of added and removed tables and materialized views are not reported separately
+	relationDiffers := func(name string) bool {
+		_, tableBefore := before.tables[name]
+		_, tableAfter := after.tables[name]
+		_, viewBefore := before.views[name]
+		_, viewAfter := after.views[name]
+		return tableBefore != tableAfter || viewBefore != viewAfter
+	}
+	for _, name := range unionKeys(before.indexes, after.indexes) {
+		object := "index " + name
+		b, inBefore := before.indexes[name]
+		a, inAfter := after.indexes[name]
+		switch {
+		case !inBefore && relationDiffers(a.Table), !inAfter && relationDiffers(b.Table):
+		case !inBefore:
+			added(object)
+		case !inAfter:
+			removed(object)
+		default:
+			changed(object, "table", b.Table, a.Table)
+			changed(object, "columns", listString(b.Columns), listString(a.Columns))
+			changed(object, "unique", fmt.Sprint(b.Unique), fmt.Sprint(a.Unique))
+		}
+	}
+	for _, name := range unionKeys(before.views, after.views) {
+		b, inBefore := before.views[name]
+		a, inAfter := after.views[name]
+		switch {
+		case !inBefore:
+			added(viewObject(a))
+		case !inAfter:
+			removed(viewObject(b))
+		case b.Materialized != a.Materialized:
+			removed(viewObject(b))
+			added(viewObject(a))
+		}
+	}
+	for _, name := range unionKeys(before.types, after.types) {
+		object := "type " + name
+		b, inBefore := before.types[name]
+		a, inAfter := after.types[name]
+		switch {
+		case !inBefore:
+			added(object)
+		case !inAfter:
+			removed(object)
+		default:
+			changed(object, "values", listString(b.Values), listString(a.Values))
+		}
+	}
+	return diff
+}
+
+func diffTables(before, after *Table) []string {
+	var diff []string
+	changed := func(object, property, from, to string) {
+		if from != to {
+			diff = append(diff, fmt.Sprintf("%s: %s changed from %s to %s", object, property, from, to))
+		}
+	}
+	table := "table " + before.Name
+	changed(table, "partition of", orNone(before.PartitionOf), orNone(after.PartitionOf))
+
+	for _, column := range before.Columns {
+		object := fmt.Sprintf("column %s.%s", before.Name, column.Name)
+		other, ok := after.Column(column.Name)
+		if !ok {
+			diff = append(diff, object+": removed")
+			continue
+		}
+		changed(object, "type", typeString(column.Type), typeString(other.Type))
+		changed(object, "not null", fmt.Sprint(column.NotNull), fmt.Sprint(other.NotNull))
+		changed(object, "default", fmt.Sprint(column.HasDefault), fmt.Sprint(other.HasDefault))
+	}
+	for _, column := range after.Columns {
+		if _, ok := before.Column(column.Name); !ok {
+			diff = append(diff, fmt.Sprintf("column %s.%s: added", before.Name, column.Name))
+		}
+	}
+
+	constraints := func(t *Table) map[string]*Constraint {
+		m := make(map[string]*Constraint, len(t.Constraints))
+		for _, constraint := range t.Constraints {
+			m[constraint.Name] = constraint
+		}
+		return m
+	}
+	beforeConstraints, afterConstraints := constraints(before), constraints(after)
+	for _, name := range unionKeys(beforeConstraints, afterConstraints) {
+		object := fmt.Sprintf("constraint %s.%s", before.Name, name)
+		b, inBefore := beforeConstraints[name]
+		a, inAfter := afterConstraints[name]
+		switch {
+		case !inBefore:
+			diff = append(diff, object+": added")
+		case !inAfter:
+			diff = append(diff, object+": removed")
+		default:
+			changed(object, "type", string(b.Type), string(a.Type))
+			changed(object, "columns", listString(b.Columns), listString(a.Columns))
+			changed(object, "not valid", fmt.Sprint(b.NotValid), fmt.Sprint(a.NotValid))
+		}
+	}
+	return diff
+}
+
+// AlreadyExists returns the objects created by the statement that already exist in the schema,
+// which make the statement fail. Statements with IF NOT EXISTS or OR REPLACE do not fail and return nil.
+func (c *Catalog) AlreadyExists(node *pg_query.Node) []string {
+	relationExists := func(name string) (string, bool) {
+		if _, ok := c.tables[name]; ok {
+			return "table " + name, true
+		}
+		if view, ok := c.views[name]; ok {
+			return viewObject(view), true
+		}
+		return "", false
+	}
+	var existing []string
+	switch {
+	case node.GetCreateStmt() != nil:
+		createStmt := node.GetCreateStmt()
+		if object, ok := relationExists(createStmt.GetRelation().GetRelname()); ok && !createStmt.GetIfNotExists() {
+			existing = append(existing, object)
+		}
+	case node.GetCreateTableAsStmt() != nil:
+		createStmt := node.GetCreateTableAsStmt()
+		if object, ok := relationExists(createStmt.GetInto().GetRel().GetRelname()); ok && !createStmt.GetIfNotExists() {
+			existing = append(existing, object)
+		}
+	case node.GetViewStmt() != nil:
+		viewStmt := node.GetViewStmt()
+		if object, ok := relationExists(viewStmt.GetView().GetRelname()); ok && !viewStmt.GetReplace() {
+			existing = append(existing, object)
+		}
+	case node.GetIndexStmt() != nil:
+		// Indexes created without a name are assigned a name that does not exist yet
+		indexStmt := node.GetIndexStmt()
+		if _, ok := c.indexes[indexStmt.GetIdxname()]; ok && !indexStmt.GetIfNotExists() {
+			existing = append(existing, "index "+indexStmt.GetIdxname())
+		}
+	case node.GetCreateEnumStmt() != nil:
+		existing = append(existing, c.typeExists(lastName(node.GetCreateEnumStmt().GetTypeName()))...)
+	case node.GetCompositeTypeStmt() != nil:
+		existing = append(existing, c.typeExists(node.GetCompositeTypeStmt().GetTypevar().GetRelname())...)
+	case node.GetCreateDomainStmt() != nil:
+		existing = append(existing, c.typeExists(lastName(node.GetCreateDomainStmt().GetDomainname()))...)
+	case node.GetAlterEnumStmt() != nil:
+		alterEnumStmt := node.GetAlterEnumStmt()
+		name := lastName(alterEnumStmt.GetTypeName())
+		if typ, ok := c.types[name]; ok && alterEnumStmt.GetOldVal() == "" && !alterEnumStmt.GetSkipIfNewValExists() &&
+			contains(typ.Values, alterEnumStmt.GetNewVal()) {
+			existing = append(existing, fmt.Sprintf("value '%s' of type %s", alterEnumStmt.GetNewVal(), name))
+		}
+	case node.GetAlterTableStmt() != nil:
+		table, ok := c.tables[node.GetAlterTableStmt().GetRelation().GetRelname()]
+		if !ok {
+			return nil
+		}
+		for _, cmd := range node.GetAlterTableStmt().GetCmds() {
+			alterTableCmd := cmd.GetAlterTableCmd()
+			switch alterTableCmd.GetSubtype() {
+			case pg_query.AlterTableType_AT_AddColumn:
+				name := alterTableCmd.GetDef().GetColumnDef().GetColname()
+				if _, ok := table.Column(name); ok && !alterTableCmd.GetMissingOk() {
+					existing = append(existing, fmt.Sprintf("column %s.%s", table.Name, name))
+				}
+			case pg_query.AlterTableType_AT_AddConstraint:
+				// Constraints defined without a name are assigned a name that does not exist yet
+				name := alterTableCmd.GetDef().GetConstraint().GetConname()
+				for _, constraint := range table.Constraints {
+					if name != "" && constraint.Name == name {
+						existing = append(existing, fmt.Sprintf("constraint %s.%s", table.Name, name))
+					}
+				}
+			}
+		}
+	}
+	return existing
+}
+
+func (c *Catalog) typeExists(name string) []string {
+	if _, ok := c.types[name]; ok {
+		return []string{"type " + name}
+	}
+	return nil
+}
+
+func viewObject(view *View) string {
+	if view.Materialized {
+		return "materialized view " + view.Name
+	}
+	return "view " + view.Name
+}
+
+// typeString returns the type name with its modifiers, e.g. varchar(100), without the pg_catalog qualifier.
+func typeString(typeName *pg_query.TypeName) string {
+	if typeName == nil {
+		return "unknown"
+	}
+	var names []string
+	for _, name := range stringValues(typeName.GetNames()) {
+		if name != "pg_catalog" {
+			names = append(names, name)
+		}
+	}
+	s := strings.Join(names, ".")
+	var modifiers []string
+	for _, typmod := range typeName.GetTypmods() {
+		modifiers = append(modifiers, fmt.Sprint(typmod.GetAConst().GetIval().GetIval()))
+	}
+	if len(modifiers) > 0 {
+		s += "(" + strings.Join(modifiers, ",") + ")"
+	}
+	return s + strings.Repeat("[]", len(typeName.GetArrayBounds()))
+}
+
+func listString(values []string) string {
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// unionKeys returns the keys of both maps, sorted.
+func unionKeys[T any](a, b map[string]T) []string {
+	union := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		union[k] = true
+	}
+	for k := range b {
+		union[k] = true
+	}
+	return sortedKeys(union)
+}
@@ -0,0 +1,150 @@
+package catalog
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []string
+	}{
+		{
+			name:   "same schema",
+			before: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);`,
+			after:  `CREATE TABLE movies (id bigint PRIMARY KEY, title text);`,
+			want:   nil,
+		},
+		{
+			name:   "added and removed tables",
+			before: `CREATE TABLE movies (id bigint PRIMARY KEY);`,
+			after:  `CREATE TABLE reviews (id bigint PRIMARY KEY);`,
+			want:   []string{"table movies: removed", "table reviews: added"},
+		},
+		{
+			name: "columns and constraints",
+			before: `CREATE TABLE movies (id bigint PRIMARY KEY, title varchar(100), rating integer);
+ALTER TABLE movies ADD CONSTRAINT movies_rating_check CHECK (rating > 0);`,
+			after: `CREATE TABLE movies (id bigint PRIMARY KEY, title text NOT NULL, budget integer);
+ALTER TABLE movies ADD CONSTRAINT movies_title_key UNIQUE (title);`,
+			want: []string{
+				"column movies.title: type changed from varchar(100) to text",
+				"column movies.title: not null changed from false to true",
+				"column movies.rating: removed",
+				"column movies.budget: added",
+				"constraint movies.movies_rating_check: removed",
+				"constraint movies.movies_title_key: added",
+				"index movies_title_key: added",
+			},
+		},
+		{
+			name: "indexes, views and types",
+			before: `CREATE TABLE movies (id bigint, title text);
+CREATE INDEX movies_title_idx ON movies (title);
+CREATE TYPE mood AS ENUM ('sad', 'happy');`,
+			after: `CREATE TABLE movies (id bigint, title text);
+CREATE INDEX movies_title_idx ON movies (id, title);
+CREATE VIEW recent_movies AS SELECT * FROM movies;
+CREATE TYPE mood AS ENUM ('sad', 'happy', 'neutral');`,
+			want: []string{
+				"index movies_title_idx: columns changed from (title) to (id, title)",
+				"view recent_movies: added",
+				"type mood: values changed from (sad, happy) to (sad, happy, neutral)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Diff(newCatalog(t, tt.before), newCatalog(t, tt.after)))
+		})
+	}
+}
+
+func TestCatalog_AlreadyExists(t *testing.T) {
+	t.Parallel()
+
+	c := newCatalog(t, `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+ALTER TABLE movies ADD CONSTRAINT movies_title_key UNIQUE (title);
+CREATE INDEX movies_id_idx ON movies (id);
+CREATE MATERIALIZED VIEW top_movies AS SELECT * FROM movies;
+CREATE VIEW recent_movies AS SELECT * FROM movies;
+CREATE TYPE mood AS ENUM ('sad', 'happy');`)
+
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "existing table",
+			sql:  `CREATE TABLE movies (id bigint);`,
+			want: []string{"table movies"},
+		},
+		{
+			name: "existing table with IF NOT EXISTS",
+			sql:  `CREATE TABLE IF NOT EXISTS movies (id bigint);`,
+			want: nil,
+		},
+		{
+			name: "existing materialized view",
+			sql:  `CREATE MATERIALIZED VIEW top_movies AS SELECT * FROM movies;`,
+			want: []string{"materialized view top_movies"},
+		},
+		{
+			name: "view with OR REPLACE",
+			sql:  `CREATE OR REPLACE VIEW recent_movies AS SELECT * FROM movies;`,
+			want: nil,
+		},
+		{
+			name: "existing index",
+			sql:  `CREATE INDEX CONCURRENTLY movies_id_idx ON movies (id);`,
+			want: []string{"index movies_id_idx"},
+		},
+		{
+			name: "unnamed index",
+			sql:  `CREATE INDEX ON movies (id);`,
+			want: nil,
+		},
+		{
+			name: "existing column and constraint",
+			sql:  `ALTER TABLE movies ADD COLUMN title text, ADD COLUMN rating integer, ADD CONSTRAINT movies_title_key UNIQUE (title);`,
+			want: []string{"column movies.title", "constraint movies.movies_title_key"},
+		},
+		{
+			name: "existing column with IF NOT EXISTS",
+			sql:  `ALTER TABLE movies ADD COLUMN IF NOT EXISTS title text;`,
+			want: nil,
+		},
+		{
+			name: "existing type",
+			sql:  `CREATE TYPE mood AS ENUM ('sad');`,
+			want: []string{"type mood"},
+		},
+		{
+			name: "existing enum value",
+			sql:  `ALTER TYPE mood ADD VALUE 'happy';`,
+			want: []string{"value 'happy' of type mood"},
+		},
+		{
+			name: "existing enum value with IF NOT EXISTS",
+			sql:  `ALTER TYPE mood ADD VALUE IF NOT EXISTS 'happy';`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, c.AlreadyExists(parse(t, tt.sql)))
+		})
+	}
+}
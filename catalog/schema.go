@@ -0,0 +1,43 @@
+package catalog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// LoadSchema reads a schema snapshot file, such as the output of pg_dump --schema-only,
+// and returns the catalog of the objects it defines.
+func LoadSchema(path string) (*Catalog, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema file: %w", err)
+	}
+	c, err := ParseSchema(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema file %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParseSchema returns the catalog of the objects defined by a schema snapshot.
+// psql meta-commands, which pg_dump may include, are skipped.
+func ParseSchema(sql string) (*Catalog, error) {
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			lines[i] = ""
+		}
+	}
+	tree, err := pg_query.Parse(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	c := New()
+	for _, statement := range tree.GetStmts() {
+		c.Apply(statement.GetStmt())
+	}
+	return c, nil
+}
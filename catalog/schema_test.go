@@ -0,0 +1,141 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pgDumpSchema = `--
+-- PostgreSQL database dump
+--
+
+\restrict abc123
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET client_encoding = 'UTF8';
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE TYPE public.mood AS ENUM (
+    'sad',
+    'happy'
+);
+
+CREATE FUNCTION public.touch_updated_at() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$;
+
+SET default_tablespace = '';
+
+CREATE TABLE public.directors (
+    id bigint NOT NULL,
+    name text NOT NULL
+);
+
+ALTER TABLE public.directors OWNER TO app;
+
+ALTER TABLE public.directors ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.directors_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+CREATE TABLE public.movies (
+    id bigint NOT NULL,
+    title character varying(100),
+    director_id bigint
+);
+
+COMMENT ON COLUMN public.movies.title IS 'Original title';
+
+CREATE TABLE public.measurements (
+    id bigint NOT NULL,
+    logged_at timestamp with time zone NOT NULL
+)
+PARTITION BY RANGE (logged_at);
+
+CREATE TABLE public.measurements_2023 (
+    id bigint NOT NULL,
+    logged_at timestamp with time zone NOT NULL
+);
+
+ALTER TABLE ONLY public.measurements ATTACH PARTITION public.measurements_2023 FOR VALUES FROM ('2023-01-01 00:00:00+00') TO ('2024-01-01 00:00:00+00');
+
+ALTER TABLE ONLY public.directors
+    ADD CONSTRAINT directors_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY public.movies
+    ADD CONSTRAINT movies_pkey PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX movies_title_idx ON public.movies USING btree (title);
+
+ALTER TABLE ONLY public.movies
+    ADD CONSTRAINT movies_director_id_fkey FOREIGN KEY (director_id) REFERENCES public.directors(id);
+
+\unrestrict abc123
+
+--
+-- PostgreSQL database dump complete
+--
+`
+
+func TestParseSchema(t *testing.T) {
+	t.Parallel()
+
+	c, err := ParseSchema(pgDumpSchema)
+	require.NoError(t, err)
+
+	directors, ok := c.Table("directors")
+	require.True(t, ok)
+	id, _ := directors.Column("id")
+	assert.True(t, id.HasDefault)
+	assert.Equal(t, []string{"directors_pkey"}, indexNames(c.Indexes("directors")))
+
+	movies, ok := c.Table("movies")
+	require.True(t, ok)
+	assert.Equal(t, []string{"id", "title", "director_id"}, columnNames(movies))
+	assert.Equal(t, []string{"movies_pkey", "movies_title_idx"}, indexNames(c.Indexes("movies")))
+	require.Len(t, movies.Constraints, 2)
+	assert.Equal(t, ForeignKey, movies.Constraints[1].Type)
+	assert.Equal(t, "directors", movies.Constraints[1].RefTable)
+
+	measurements, ok := c.Table("measurements")
+	require.True(t, ok)
+	assert.True(t, measurements.Partitioned)
+	partition, ok := c.Table("measurements_2023")
+	require.True(t, ok)
+	assert.Equal(t, "measurements", partition.PartitionOf)
+
+	mood, ok := c.Type("mood")
+	require.True(t, ok)
+	assert.Equal(t, []string{"sad", "happy"}, mood.Values)
+}
+
+func TestLoadSchema(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	require.NoError(t, os.WriteFile(path, []byte(pgDumpSchema), 0o600))
+	c, err := LoadSchema(path)
+	require.NoError(t, err)
+	assert.Len(t, c.Tables(), 4)
+
+	require.NoError(t, os.WriteFile(path, []byte(`CREATE TABLE movies (`), 0o600))
+	_, err = LoadSchema(path)
+	assert.Error(t, err)
+
+	_, err = LoadSchema(filepath.Join(t.TempDir(), "missing.sql"))
+	assert.Error(t, err)
+}
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	pg_query "github.com/pganalyze/pg_query_go/v4"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
+	"io"
 	"os"
+	"pgsafemigrate/catalog"
+	"pgsafemigrate/config"
+	"pgsafemigrate/hints"
 	"pgsafemigrate/loader"
+	"pgsafemigrate/locks"
 	"pgsafemigrate/reporter"
 	"pgsafemigrate/rules"
 	"strings"
@@ -14,21 +20,57 @@ import (
 )
 
 // Check processes the migration files at the given paths and produces a report.
-// The returned error will signal a non-zero exit code for the CLI.
-func Check(_ *cli.Context, paths []string, excludedRules []string, output reporter.Reporter) error {
+// Migration files are processed in the order of their file names, and each migration
+// is aware of the schema built from the schema snapshot, if any, and the migrations preceding it,
+// along with the order in which the preceding migrations lock tables.
+// The returned error will signal a non-zero exit code for the CLI, unless all the violations have the info severity.
+func Check(_ *cli.Context, paths []string, settings Settings, output reporter.Reporter) error {
 	migrationFiles, err := loader.ReadStatementsFromFiles(paths...)
 	if err != nil {
 		return err
 	}
-	var failed bool
-	for _, m := range migrationFiles {
-		results, err := rules.ProcessMigration(m, excludedRules)
+	cat := catalog.New()
+	if settings.SchemaPath != "" {
+		cat, err = catalog.LoadSchema(settings.SchemaPath)
+		if err != nil {
+			return err
+		}
+	}
+	var (
+		failed  bool
+		history = locks.NewHistory()
+	)
+	for _, m := range loader.SortedByName(migrationFiles) {
+		results, err := rules.ProcessMigration(m, settings.RuleSet, rules.Target{
+			Catalog:         cat,
+			PostgresVersion: settings.PostgresVersion,
+			TableHints:      settings.TableHints,
+			LockHistory:     history,
+		})
 		if err != nil {
 			panic(err)
 		}
+		migration, err := loader.LoadMigration(m.Contents)
+		if err != nil {
+			return err
+		}
+		upStatements, err := rules.UpStatements(m)
+		if err != nil {
+			return err
+		}
+		var timelineStatements []locks.Statement
+		for _, statement := range upStatements {
+			timelineStatements = append(timelineStatements, locks.Statement{Node: statement})
+		}
+		history.Record(m.Path, locks.Timeline(timelineStatements, !migration.DisableTransactionUp, cat))
+		for _, statement := range upStatements {
+			cat.Apply(statement)
+		}
 		var reports []reporter.Report
 		for _, r := range results {
-			failed = failed || len(r.Errors) > 0
+			for _, e := range r.Errors {
+				failed = failed || e.Severity().Fails()
+			}
 			reports = append(reports, reporter.NewReport(m.Path, r.Errors))
 		}
 
@@ -42,8 +84,252 @@ func Check(_ *cli.Context, paths []string, excludedRules []string, output report
 	return nil
 }
 
+// Options contains the command-line options of the check command.
+type Options struct {
+	ConfigPath      string
+	EnabledRules    []string
+	ExcludedRules   []string
+	PostgresVersion int
+	SchemaPath      string
+	TableHintsPath  string
+}
+
+// Settings contains the settings of the check command, merged from the configuration file and the command-line options.
+type Settings struct {
+	RuleSet rules.RuleSet
+	// PostgresVersion is the target PostgreSQL major version, zero when unknown.
+	PostgresVersion int
+	// SchemaPath is the path to a schema snapshot of the database before the migrations, e.g. a pg_dump --schema-only output.
+	SchemaPath string
+	TableHints hints.Hints
+}
+
+// ConfiguredSettings returns the check settings, merged from the configuration file and the command-line options.
+// Opt-in rules are enabled and rules are excluded when defined either in the configuration file or the command-line options.
+// The PostgreSQL version option takes precedence over the configuration file, and only the rules that apply to it are enabled.
+// The table hints file takes precedence over the table hints of the configuration file.
+// The configuration file is optional and ignored when the path is empty.
+func ConfiguredSettings(opts Options) (Settings, error) {
+	var cfg config.Config
+	if opts.ConfigPath != "" {
+		var err error
+		cfg, err = config.Load(opts.ConfigPath)
+		if err != nil {
+			return Settings{}, err
+		}
+	}
+	ruleSet, err := rules.All().Configure(cfg.RuleSettings())
+	if err != nil {
+		return Settings{}, err
+	}
+	for _, alias := range append(cfg.EnabledRules, cfg.ExcludedRules...) {
+		if !ruleSet.Contains(alias) {
+			return Settings{}, fmt.Errorf("unknown alias %q", alias)
+		}
+	}
+	postgresVersion := opts.PostgresVersion
+	if postgresVersion == 0 {
+		postgresVersion = cfg.PostgresVersion
+	}
+	if postgresVersion < 0 {
+		return Settings{}, fmt.Errorf("invalid PostgreSQL version %d", postgresVersion)
+	}
+	tableHints := cfg.TableHints
+	if opts.TableHintsPath != "" {
+		fileHints, err := hints.Load(opts.TableHintsPath)
+		if err != nil {
+			return Settings{}, err
+		}
+		tableHints = tableHints.Merge(fileHints)
+	}
+	return Settings{
+		RuleSet: ruleSet.
+			Enabled(append(cfg.EnabledRules, opts.EnabledRules...)...).
+			Except(append(cfg.ExcludedRules, opts.ExcludedRules...)...).
+			ForVersion(postgresVersion),
+		PostgresVersion: postgresVersion,
+		SchemaPath:      opts.SchemaPath,
+		TableHints:      tableHints,
+	}, nil
+}
+
+// Locks prints the locks acquired by each statement of the migration files at the given paths,
+// and the locks held until the end of each transaction. Migration files are processed in the order
+// of their file names, and indexes are resolved to their tables using the schema built from the
+// schema snapshot, if any, and the preceding migrations.
+func Locks(_ *cli.Context, paths []string, schemaPath string) error {
+	migrationFiles, err := loader.ReadStatementsFromFiles(paths...)
+	if err != nil {
+		return err
+	}
+	cat := catalog.New()
+	if schemaPath != "" {
+		cat, err = catalog.LoadSchema(schemaPath)
+		if err != nil {
+			return err
+		}
+	}
+	w := os.Stdout
+	for _, m := range loader.SortedByName(migrationFiles) {
+		migration, err := loader.LoadMigration(m.Contents)
+		if err != nil {
+			return err
+		}
+		upStatements, err := parsedStatements(migration.UpStatements)
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.Path, err)
+		}
+		downStatements, err := parsedStatements(migration.DownStatements)
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.Path, err)
+		}
+		if _, err := fmt.Fprintf(w, "File %s\n", m.Path); err != nil {
+			return err
+		}
+		if err := printTimeline(w, "Up", locks.Timeline(upStatements, !migration.DisableTransactionUp, cat)); err != nil {
+			return err
+		}
+		// The rollback is executed after the forward migration
+		for _, statement := range upStatements {
+			cat.Apply(statement.Node)
+		}
+		if err := printTimeline(w, "Down", locks.Timeline(downStatements, !migration.DisableTransactionDown, cat)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Roundtrip applies, for each migration file at the given paths, the forward migration, the rollback and the forward
+// migration again to the schema built from the schema snapshot, if any, and the preceding migrations.
+// It reports the differences between the schema before the forward migration and after the rollback,
+// and the statements of the second forward migration that fail because the objects they create already exist.
+// Migration files are processed in the order of their file names.
+// The returned error will signal a non-zero exit code for the CLI if any problems are found.
+func Roundtrip(_ *cli.Context, paths []string, schemaPath string) error {
+	migrationFiles, err := loader.ReadStatementsFromFiles(paths...)
+	if err != nil {
+		return err
+	}
+	cat := catalog.New()
+	if schemaPath != "" {
+		cat, err = catalog.LoadSchema(schemaPath)
+		if err != nil {
+			return err
+		}
+	}
+	var failed bool
+	for _, m := range loader.SortedByName(migrationFiles) {
+		migration, err := loader.LoadMigration(m.Contents)
+		if err != nil {
+			return err
+		}
+		upStatements, err := parsedStatements(migration.UpStatements)
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.Path, err)
+		}
+		downStatements, err := parsedStatements(migration.DownStatements)
+		if err != nil {
+			return fmt.Errorf("%s: %w", m.Path, err)
+		}
+		before := cat.Clone()
+		for _, statement := range upStatements {
+			cat.Apply(statement.Node)
+		}
+		rolledBack := cat.Clone()
+		for _, statement := range downStatements {
+			rolledBack.Apply(statement.Node)
+		}
+
+		var out strings.Builder
+		out.WriteString(fmt.Sprintf("File %s\n", m.Path))
+		diff := catalog.Diff(before, rolledBack)
+		if len(diff) > 0 {
+			out.WriteString("  Schema differences after Up and Down:\n")
+			for _, d := range diff {
+				out.WriteString(fmt.Sprintf("    %s\n", d))
+			}
+		}
+		var conflicts []string
+		for i, statement := range upStatements {
+			if existing := rolledBack.AlreadyExists(statement.Node); len(existing) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("    [%d] %s\n        already exists: %s\n",
+					i+1, strings.ReplaceAll(statement.SQL, "\n", "\n        "), strings.Join(existing, ", ")))
+			}
+			rolledBack.Apply(statement.Node)
+		}
+		if len(conflicts) > 0 {
+			out.WriteString("  Statements failing when Up is applied again:\n")
+			out.WriteString(strings.Join(conflicts, ""))
+		}
+		if len(diff) == 0 && len(conflicts) == 0 {
+			out.WriteString("  No differences after Up and Down\n")
+		}
+		failed = failed || len(diff) > 0 || len(conflicts) > 0
+		fmt.Print(out.String())
+	}
+	if failed {
+		return cli.Exit("\u274c Problems found.", 1)
+	}
+	fmt.Println("\u2713 No problems found!")
+	return nil
+}
+
+func parsedStatements(sqlStatements []string) ([]locks.Statement, error) {
+	var statements []locks.Statement
+	for _, sql := range sqlStatements {
+		tree, err := pg_query.Parse(sql)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range tree.GetStmts() {
+			statements = append(statements, locks.Statement{SQL: strings.TrimSpace(sql), Node: s.GetStmt()})
+		}
+	}
+	return statements, nil
+}
+
+func printTimeline(w io.Writer, direction string, transactions []locks.Transaction) error {
+	var (
+		out strings.Builder
+		n   int
+	)
+	for i, transaction := range transactions {
+		out.WriteString(fmt.Sprintf("  %s transaction %d:\n", direction, i+1))
+		first := n + 1
+		for _, statement := range transaction.Statements {
+			n++
+			out.WriteString(fmt.Sprintf("    [%d] %s\n", n, strings.ReplaceAll(statement.SQL, "\n", "\n        ")))
+			for _, lock := range statement.Locks {
+				out.WriteString(fmt.Sprintf("        %s on %s%s\n", lock.Mode, lock.Relation, blockedQueries(lock.Mode)))
+			}
+		}
+		held := transaction.Held()
+		if len(held) == 0 {
+			continue
+		}
+		out.WriteString("    Locks held until the end of the transaction:\n")
+		for _, lock := range held {
+			out.WriteString(fmt.Sprintf("        %s on %s, from [%d] to [%d]\n", lock.Mode, lock.Relation, first+lock.From, n))
+		}
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func blockedQueries(mode locks.Mode) string {
+	switch {
+	case mode.BlocksReads():
+		return " (blocks reads and writes)"
+	case mode.BlocksWrites():
+		return " (blocks writes)"
+	}
+	return ""
+}
+
 // ListRules list all available rules sorted by alias.
-// The output includes the category and the documentation guide for each rule.
+// The output includes the category, whether the rule is opt-in, the PostgreSQL versions it applies to
+// and the documentation guide for each rule.
 func ListRules(_ *cli.Context) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
 	toTitle := cases.Title(language.AmericanEnglish).String
@@ -51,8 +337,12 @@ func ListRules(_ *cli.Context) error {
 		return toTitle(strings.ReplaceAll(rules.CategoryFromAlias(alias), "-", " "))
 	}
 	for _, rule := range rules.All().SortedSlice() {
-		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n",
-			aliasToTitle(rule.Alias()), rule.Alias(), rule.Documentation()); err != nil {
+		status := "enabled"
+		if rules.IsOptIn(rule.Alias()) {
+			status = "opt-in"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			aliasToTitle(rule.Alias()), rule.Alias(), status, rules.PostgresVersions(rule), rule.Documentation()); err != nil {
 			return err
 		}
 	}
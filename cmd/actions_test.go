@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgsafemigrate/hints"
+	"pgsafemigrate/rules"
+)
+
+func TestConfiguredSettings(t *testing.T) {
+	t.Parallel()
+
+	var (
+		enumAddValue     = rules.EnumAddValueInTransaction{}.Alias()
+		statementTimeout = rules.StatementTimeoutRequired{}.Alias()
+		indexName        = rules.IndexMustBeNamed{}.Alias()
+	)
+	tests := []struct {
+		name         string
+		config       string
+		tableHints   string
+		opts         Options
+		wantErr      bool
+		wantVersion  int
+		wantHints    hints.Hints
+		wantRules    []string
+		wantNotRules []string
+	}{
+		{
+			name:         "no configuration file",
+			wantRules:    []string{indexName},
+			wantNotRules: []string{statementTimeout},
+		},
+		{
+			name: "configuration file",
+			config: `postgres-version: 11
+enabled-rules: [high-availability-require-statement-timeout]
+excluded-rules: [maintainability-indexes-name-is-required]
+table-hints:
+  tables:
+    events:
+      rows: 1000`,
+			wantVersion:  11,
+			wantHints:    hints.Hints{Tables: map[string]hints.Table{"events": {Rows: 1000}}},
+			wantRules:    []string{enumAddValue, statementTimeout},
+			wantNotRules: []string{indexName},
+		},
+		{
+			name: "options override the configuration file",
+			config: `postgres-version: 11
+table-hints:
+  tables:
+    events:
+      rows: 1000
+    users:
+      rows: 10`,
+			tableHints: `tables:
+  events:
+    rows: 500000000
+    hot: true`,
+			opts: Options{
+				PostgresVersion: 14,
+				EnabledRules:    []string{statementTimeout},
+				ExcludedRules:   []string{indexName},
+			},
+			wantVersion: 14,
+			wantHints: hints.Hints{Tables: map[string]hints.Table{
+				"events": {Rows: 500_000_000, Hot: true},
+				"users":  {Rows: 10},
+			}},
+			wantRules:    []string{statementTimeout},
+			wantNotRules: []string{enumAddValue, indexName},
+		},
+		{
+			name:    "missing configuration file",
+			opts:    Options{ConfigPath: filepath.Join(t.TempDir(), "missing.yml")},
+			wantErr: true,
+		},
+		{
+			name: "invalid rule configuration",
+			config: `rules:
+  high-availability-limit-locks-per-transaction:
+    max-access-exclusive-relations: 0`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown rule alias",
+			config:  `excluded-rules: [unknown-rule]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid PostgreSQL version",
+			opts:    Options{PostgresVersion: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := tt.opts
+			if tt.config != "" {
+				opts.ConfigPath = filepath.Join(t.TempDir(), "pgsafemigrate.yml")
+				require.NoError(t, os.WriteFile(opts.ConfigPath, []byte(tt.config), 0o600))
+			}
+			if tt.tableHints != "" {
+				opts.TableHintsPath = filepath.Join(t.TempDir(), "hints.yml")
+				require.NoError(t, os.WriteFile(opts.TableHintsPath, []byte(tt.tableHints), 0o600))
+			}
+
+			settings, err := ConfiguredSettings(opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, settings.PostgresVersion)
+			assert.Equal(t, tt.wantHints, settings.TableHints)
+			for _, alias := range tt.wantRules {
+				assert.True(t, settings.RuleSet.Contains(alias), alias)
+			}
+			for _, alias := range tt.wantNotRules {
+				assert.False(t, settings.RuleSet.Contains(alias), alias)
+			}
+		})
+	}
+}
@@ -11,18 +11,65 @@ import (
 // that will be ignored for all files.
 func ExcludedRulesFlag() *cli.StringSliceFlag {
 	return &cli.StringSliceFlag{
-		Name: "excluded-rules",
-		Action: func(cCtx *cli.Context, inputs []string) error {
-			var allAliases []string
-			for _, in := range inputs {
-				allAliases = append(allAliases, strings.Split(in, ",")...)
-			}
-			for _, alias := range allAliases {
-				if !rules.All().Contains(alias) {
-					return cli.Exit(fmt.Sprintf("unknown alias %q", alias), 1)
-				}
-			}
-			return nil
-		},
+		Name:   "excluded-rules",
+		Action: validateAliases,
+	}
+}
+
+// EnabledRulesFlag defines an --enabled-rules option for providing the aliases of opt-in rules
+// that will be enabled for all files.
+func EnabledRulesFlag() *cli.StringSliceFlag {
+	return &cli.StringSliceFlag{
+		Name:   "enabled-rules",
+		Action: validateAliases,
+	}
+}
+
+func validateAliases(_ *cli.Context, inputs []string) error {
+	var allAliases []string
+	for _, in := range inputs {
+		allAliases = append(allAliases, strings.Split(in, ",")...)
+	}
+	for _, alias := range allAliases {
+		if !rules.All().Contains(alias) {
+			return cli.Exit(fmt.Sprintf("unknown alias %q", alias), 1)
+		}
+	}
+	return nil
+}
+
+// ConfigFlag defines a --config option for providing the path to a YAML configuration file.
+func ConfigFlag() *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:      "config",
+		Usage:     "path to a YAML configuration file",
+		TakesFile: true,
+	}
+}
+
+// PostgresVersionFlag defines a --postgres-version option for providing the target PostgreSQL major version.
+func PostgresVersionFlag() *cli.IntFlag {
+	return &cli.IntFlag{
+		Name:  "postgres-version",
+		Usage: "target PostgreSQL major version, e.g. 14",
+	}
+}
+
+// SchemaFlag defines a --schema option for providing the path to a schema snapshot, e.g. a pg_dump --schema-only output.
+func SchemaFlag() *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:      "schema",
+		Usage:     "path to a schema-only SQL dump of the database before the migrations",
+		TakesFile: true,
+	}
+}
+
+// TableHintsFlag defines a --table-hints option for providing the path to a YAML or JSON file
+// with the approximate size and traffic of the tables.
+func TableHintsFlag() *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:      "table-hints",
+		Usage:     "path to a YAML or JSON file with the row counts and hot flags of the tables",
+		TakesFile: true,
 	}
 }
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+	"pgsafemigrate/hints"
+)
+
+// Config contains the settings loaded from a YAML configuration file:
+//
+//	postgres-version: 14
+//	enabled-rules:
+//	  - high-availability-require-statement-timeout
+//	excluded-rules:
+//	  - maintainability-indexes-name-is-required
+//	rules:
+//	  high-availability-avoid-volatile-column-default:
+//	    volatile-functions: [random, gen_random_uuid]
+//	table-hints:
+//	  tables:
+//	    events:
+//	      rows: 500000000
+type Config struct {
+	// PostgresVersion is the target PostgreSQL major version, zero when unknown.
+	PostgresVersion int                  `yaml:"postgres-version"`
+	EnabledRules    []string             `yaml:"enabled-rules"`
+	ExcludedRules   []string             `yaml:"excluded-rules"`
+	Rules           map[string]yaml.Node `yaml:"rules"`
+	TableHints      hints.Hints          `yaml:"table-hints"`
+}
+
+// Load reads the configuration file at the given path.
+func Load(path string) (Config, error) {
+	var cfg Config
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := yaml.Unmarshal(contents, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// RuleSettings returns a decoding function for the settings of each rule, keyed by the rule alias.
+func (c Config) RuleSettings() map[string]func(any) error {
+	settings := make(map[string]func(any) error, len(c.Rules))
+	for alias, node := range c.Rules {
+		node := node
+		settings[alias] = node.Decode
+	}
+	return settings
+}
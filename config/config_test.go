@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgsafemigrate/hints"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pgsafemigrate.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+postgres-version: 14
+enabled-rules:
+  - high-availability-require-statement-timeout
+excluded-rules:
+  - maintainability-indexes-name-is-required
+rules:
+  high-availability-avoid-volatile-column-default:
+    volatile-functions: [random, my_random]
+table-hints:
+  tables:
+    events:
+      rows: 500000000
+      hot: true
+`), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 14, cfg.PostgresVersion)
+	assert.Equal(t, []string{"high-availability-require-statement-timeout"}, cfg.EnabledRules)
+	assert.Equal(t, []string{"maintainability-indexes-name-is-required"}, cfg.ExcludedRules)
+	assert.Equal(t, hints.Table{Rows: 500_000_000, Hot: true}, cfg.TableHints.Tables["events"])
+
+	settings := cfg.RuleSettings()
+	require.Contains(t, settings, "high-availability-avoid-volatile-column-default")
+	var ruleSettings struct {
+		VolatileFunctions []string `yaml:"volatile-functions"`
+	}
+	require.NoError(t, settings["high-availability-avoid-volatile-column-default"](&ruleSettings))
+	assert.Equal(t, []string{"random", "my_random"}, ruleSettings.VolatileFunctions)
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "pgsafemigrate.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`excluded-rules: {`), 0o600))
+
+	_, err := Load(path)
+	assert.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Package hints contains the approximate size and traffic of the tables,
+// e.g. generated periodically from pg_stat_user_tables, which scope the rules that report locks.
+package hints
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultSmallTableRows is the default maximum number of rows of a small table.
+	DefaultSmallTableRows = 10_000
+	// DefaultLargeTableRows is the default minimum number of rows of a large table.
+	DefaultLargeTableRows = 10_000_000
+)
+
+// Hints contains the table hints, loaded from a YAML or JSON file:
+//
+//	small-table-rows: 10000
+//	large-table-rows: 10000000
+//	tables:
+//	  countries:
+//	    rows: 250
+//	  events:
+//	    rows: 500000000
+//	    hot: true
+type Hints struct {
+	// SmallTableRows is the maximum number of rows of a small table. DefaultSmallTableRows is used when zero.
+	SmallTableRows int64 `yaml:"small-table-rows"`
+	// LargeTableRows is the minimum number of rows of a large table. DefaultLargeTableRows is used when zero.
+	LargeTableRows int64            `yaml:"large-table-rows"`
+	Tables         map[string]Table `yaml:"tables"`
+}
+
+// Table contains the hints of a table.
+type Table struct {
+	// Rows is the approximate number of rows.
+	Rows int64 `yaml:"rows"`
+	// Hot is set for tables with heavy traffic, regardless of their size.
+	Hot bool `yaml:"hot"`
+}
+
+// Size is the size class of a table.
+type Size int
+
+const (
+	// Unknown is the size of tables without hints, or between the small and large thresholds.
+	Unknown Size = iota
+	Small
+	Large
+)
+
+// Load reads the table hints file at the given path. JSON files are also supported, as a subset of YAML.
+func Load(path string) (Hints, error) {
+	var h Hints
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return h, err
+	}
+	if err := yaml.Unmarshal(contents, &h); err != nil {
+		return h, fmt.Errorf("invalid table hints file %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Merge returns the hints with the given hints applied on top. Thresholds are replaced when set,
+// and the hints of each table are replaced by the hints of the same table.
+func (h Hints) Merge(other Hints) Hints {
+	merged := Hints{
+		SmallTableRows: h.SmallTableRows,
+		LargeTableRows: h.LargeTableRows,
+		Tables:         make(map[string]Table, len(h.Tables)+len(other.Tables)),
+	}
+	if other.SmallTableRows != 0 {
+		merged.SmallTableRows = other.SmallTableRows
+	}
+	if other.LargeTableRows != 0 {
+		merged.LargeTableRows = other.LargeTableRows
+	}
+	for name, table := range h.Tables {
+		merged.Tables[name] = table
+	}
+	for name, table := range other.Tables {
+		merged.Tables[name] = table
+	}
+	return merged
+}
+
+// Size returns the size class of a group of tables, e.g. the tables locked by a statement.
+// The tables are large when any of them is large or hot, and small when all of them are known to be small.
+func (h Hints) Size(tables ...string) Size {
+	if len(tables) == 0 {
+		return Unknown
+	}
+	small, large := h.SmallTableRows, h.LargeTableRows
+	if small == 0 {
+		small = DefaultSmallTableRows
+	}
+	if large == 0 {
+		large = DefaultLargeTableRows
+	}
+	allSmall := true
+	for _, name := range tables {
+		table, ok := h.Tables[name]
+		if ok && (table.Hot || table.Rows >= large) {
+			return Large
+		}
+		if !ok || table.Rows > small {
+			allSmall = false
+		}
+	}
+	if allSmall {
+		return Small
+	}
+	return Unknown
+}
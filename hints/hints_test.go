@@ -0,0 +1,98 @@
+package hints
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHints_Size(t *testing.T) {
+	t.Parallel()
+
+	h := Hints{Tables: map[string]Table{
+		"countries":  {Rows: 250},
+		"currencies": {Rows: 150},
+		"movies":     {Rows: 500_000},
+		"events":     {Rows: 500_000_000},
+		"sessions":   {Rows: 1_000, Hot: true},
+	}}
+	tests := []struct {
+		name   string
+		hints  Hints
+		tables []string
+		want   Size
+	}{
+		{name: "no tables", hints: h, want: Unknown},
+		{name: "table without hints", hints: h, tables: []string{"directors"}, want: Unknown},
+		{name: "small table", hints: h, tables: []string{"countries"}, want: Small},
+		{name: "all tables are small", hints: h, tables: []string{"countries", "currencies"}, want: Small},
+		{name: "small and unknown tables", hints: h, tables: []string{"countries", "directors"}, want: Unknown},
+		{name: "medium table", hints: h, tables: []string{"movies"}, want: Unknown},
+		{name: "large table", hints: h, tables: []string{"events"}, want: Large},
+		{name: "small and large tables", hints: h, tables: []string{"countries", "events"}, want: Large},
+		{name: "hot table", hints: h, tables: []string{"sessions"}, want: Large},
+		{
+			name:   "custom thresholds",
+			hints:  Hints{SmallTableRows: 1_000_000, LargeTableRows: 100_000_000, Tables: h.Tables},
+			tables: []string{"movies"},
+			want:   Small,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.hints.Size(tt.tables...))
+		})
+	}
+}
+
+func TestHints_Merge(t *testing.T) {
+	t.Parallel()
+
+	h := Hints{SmallTableRows: 100, LargeTableRows: 1_000, Tables: map[string]Table{
+		"countries": {Rows: 250},
+		"events":    {Rows: 500},
+	}}
+	merged := h.Merge(Hints{LargeTableRows: 2_000, Tables: map[string]Table{"events": {Rows: 5_000, Hot: true}}})
+
+	assert.Equal(t, Hints{SmallTableRows: 100, LargeTableRows: 2_000, Tables: map[string]Table{
+		"countries": {Rows: 250},
+		"events":    {Rows: 5_000, Hot: true},
+	}}, merged)
+	assert.Equal(t, Table{Rows: 500}, h.Tables["events"])
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "hints.yml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+small-table-rows: 1000
+tables:
+  events:
+    rows: 500000000
+    hot: true
+`), 0o600))
+	h, err := Load(yamlPath)
+	require.NoError(t, err)
+	assert.Equal(t, Hints{SmallTableRows: 1000, Tables: map[string]Table{"events": {Rows: 500_000_000, Hot: true}}}, h)
+
+	jsonPath := filepath.Join(dir, "hints.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"tables": {"countries": {"rows": 250}}}`), 0o600))
+	h, err = Load(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, Hints{Tables: map[string]Table{"countries": {Rows: 250}}}, h)
+
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`tables: [`), 0o600))
+	_, err = Load(yamlPath)
+	assert.Error(t, err)
+
+	_, err = Load(filepath.Join(dir, "missing.yml"))
+	assert.Error(t, err)
+}
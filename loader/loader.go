@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"pgsafemigrate/annotations"
+	"sort"
 	"strings"
 )
 
@@ -89,6 +90,17 @@ func ReadStatementsFromFiles(paths ...string) ([]MigrationFile, error) {
 	return statements, nil
 }
 
+// SortedByName returns the migration files sorted by their file name,
+// which is the order that the migrations are applied.
+func SortedByName(migrationFiles []MigrationFile) []MigrationFile {
+	sorted := make([]MigrationFile, len(migrationFiles))
+	copy(sorted, migrationFiles)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return filepath.Base(sorted[i].Path) < filepath.Base(sorted[j].Path)
+	})
+	return sorted
+}
+
 type Comment struct {
 	Content              string
 	TokenIndex           int
@@ -101,6 +113,21 @@ func (c Comment) IsDown() bool {
 	return c.SQLMigrateAnnotation && c.SQLMigrateDirection == migrate.Down
 }
 
+// HasDirectionAnnotations reports whether the migration defines its directions with sql-migrate annotations,
+// i.e. whether the migration is in the sql-migrate format rather than a plain SQL script.
+func HasDirectionAnnotations(sql string) bool {
+	comments, err := ScanCommentsFromString(sql)
+	if err != nil {
+		return false
+	}
+	for _, c := range comments {
+		if c.SQLMigrateAnnotation {
+			return true
+		}
+	}
+	return false
+}
+
 func ScanCommentsFromString(sql string) ([]Comment, error) {
 	scanRes, err := pg_query.Scan(sql)
 	if err != nil {
@@ -228,3 +228,41 @@ SELECT 1;
 		})
 	}
 }
+
+func TestSortedByName(t *testing.T) {
+	files := []MigrationFile{
+		{Path: "/migrations/b/20231013091220-add-index.sql"},
+		{Path: "/migrations/a/20230930091220-create-table.sql"},
+		{Path: "/migrations/20231001000000-add-column.sql"},
+	}
+	assert.Equal(t, []MigrationFile{
+		{Path: "/migrations/a/20230930091220-create-table.sql"},
+		{Path: "/migrations/20231001000000-add-column.sql"},
+		{Path: "/migrations/b/20231013091220-add-index.sql"},
+	}, SortedByName(files))
+	assert.Equal(t, "/migrations/b/20231013091220-add-index.sql", files[0].Path)
+}
+
+func TestHasDirectionAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "sql-migrate formatted",
+			sql:  "-- +migrate Up\nCREATE TABLE movies (id bigint);\n",
+			want: true,
+		},
+		{
+			name: "plain SQL script",
+			sql:  "-- create the movies table\nCREATE TABLE movies (id bigint);\n",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HasDirectionAnnotations(tt.sql))
+		})
+	}
+}
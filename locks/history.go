@@ -0,0 +1,49 @@
+package locks
+
+// History contains the order in which the transactions of the preceding migrations acquire locks on relations.
+// Only the locks that block writes are recorded.
+type History struct {
+	// orders maps a pair of relations to the path of a migration that locks the first relation before the second one.
+	orders map[[2]string]string
+}
+
+// NewHistory returns an empty history.
+func NewHistory() *History {
+	return &History{orders: make(map[[2]string]string)}
+}
+
+// Record adds the lock order of the transactions of a migration file.
+func (h *History) Record(path string, transactions []Transaction) {
+	for _, transaction := range transactions {
+		var locked []string
+		for _, lock := range transaction.Held() {
+			if !lock.Mode.BlocksWrites() || contains(locked, lock.Relation) {
+				continue
+			}
+			for _, relation := range locked {
+				if _, ok := h.orders[[2]string{relation, lock.Relation}]; !ok {
+					h.orders[[2]string{relation, lock.Relation}] = path
+				}
+			}
+			locked = append(locked, lock.Relation)
+		}
+	}
+}
+
+// LockedBefore returns the path of a migration that locks the first relation before the second one, in the same transaction.
+func (h *History) LockedBefore(first, second string) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	path, ok := h.orders[[2]string{first, second}]
+	return path, ok
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
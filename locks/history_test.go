@@ -0,0 +1,37 @@
+package locks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	h := NewHistory()
+	h.Record("1.sql", Timeline(statements(t, `ALTER TABLE movies ADD COLUMN rating integer;
+SELECT * FROM reviews;
+ALTER TABLE directors ADD COLUMN born_at date;
+CREATE INDEX awards_movie_id_idx ON awards (movie_id);`), true, nil))
+	h.Record("2.sql", Timeline(statements(t, `ALTER TABLE awards ADD COLUMN year integer;
+ALTER TABLE movies ADD COLUMN budget integer;`), true, nil))
+
+	path, ok := h.LockedBefore("movies", "directors")
+	assert.True(t, ok)
+	assert.Equal(t, "1.sql", path)
+	path, ok = h.LockedBefore("movies", "awards")
+	assert.True(t, ok)
+	assert.Equal(t, "1.sql", path)
+	path, ok = h.LockedBefore("awards", "movies")
+	assert.True(t, ok)
+	assert.Equal(t, "2.sql", path)
+	_, ok = h.LockedBefore("directors", "movies")
+	assert.False(t, ok)
+	_, ok = h.LockedBefore("movies", "reviews")
+	assert.False(t, ok)
+
+	var empty *History
+	_, ok = empty.LockedBefore("movies", "directors")
+	assert.False(t, ok)
+}
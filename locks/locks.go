@@ -0,0 +1,380 @@
+// Package locks determines the PostgreSQL table-level locks acquired by migration statements,
+// and how long they are held within the transactions of a migration.
+package locks
+
+import (
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/catalog"
+)
+
+// Mode is a table-level lock mode, ordered from the weakest to the strongest.
+// The values match the lock mode numbers of PostgreSQL and the LOCK statement parse tree.
+// https://www.postgresql.org/docs/current/explicit-locking.html#LOCKING-TABLES
+type Mode int
+
+const (
+	AccessShare Mode = iota + 1
+	RowShare
+	RowExclusive
+	ShareUpdateExclusive
+	Share
+	ShareRowExclusive
+	Exclusive
+	AccessExclusive
+)
+
+func (m Mode) String() string {
+	switch m {
+	case AccessShare:
+		return "ACCESS SHARE"
+	case RowShare:
+		return "ROW SHARE"
+	case RowExclusive:
+		return "ROW EXCLUSIVE"
+	case ShareUpdateExclusive:
+		return "SHARE UPDATE EXCLUSIVE"
+	case Share:
+		return "SHARE"
+	case ShareRowExclusive:
+		return "SHARE ROW EXCLUSIVE"
+	case Exclusive:
+		return "EXCLUSIVE"
+	case AccessExclusive:
+		return "ACCESS EXCLUSIVE"
+	}
+	return "UNKNOWN"
+}
+
+// conflicts contains the modes that conflict with each lock mode.
+var conflicts = map[Mode][]Mode{
+	AccessShare:          {AccessExclusive},
+	RowShare:             {Exclusive, AccessExclusive},
+	RowExclusive:         {Share, ShareRowExclusive, Exclusive, AccessExclusive},
+	ShareUpdateExclusive: {ShareUpdateExclusive, Share, ShareRowExclusive, Exclusive, AccessExclusive},
+	Share:                {RowExclusive, ShareUpdateExclusive, ShareRowExclusive, Exclusive, AccessExclusive},
+	ShareRowExclusive:    {RowExclusive, ShareUpdateExclusive, Share, ShareRowExclusive, Exclusive, AccessExclusive},
+	Exclusive:            {RowShare, RowExclusive, ShareUpdateExclusive, Share, ShareRowExclusive, Exclusive, AccessExclusive},
+	AccessExclusive:      {AccessShare, RowShare, RowExclusive, ShareUpdateExclusive, Share, ShareRowExclusive, Exclusive, AccessExclusive},
+}
+
+// Conflicts reports whether a lock of this mode blocks a lock of the other mode on the same relation.
+func (m Mode) Conflicts(other Mode) bool {
+	for _, mode := range conflicts[m] {
+		if mode == other {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether a lock of this mode conflicts with all the modes that the other mode conflicts with,
+// in which case acquiring the other mode when this mode is held blocks no additional queries.
+func (m Mode) covers(other Mode) bool {
+	for _, mode := range conflicts[other] {
+		if !m.Conflicts(mode) {
+			return false
+		}
+	}
+	return true
+}
+
+// BlocksReads reports whether the lock blocks SELECT queries.
+func (m Mode) BlocksReads() bool {
+	return m.Conflicts(AccessShare)
+}
+
+// BlocksWrites reports whether the lock blocks INSERT, UPDATE and DELETE queries.
+func (m Mode) BlocksWrites() bool {
+	return m.Conflicts(RowExclusive)
+}
+
+// Lock is a lock acquired on a table, index, view or sequence.
+type Lock struct {
+	Relation string
+	Mode     Mode
+}
+
+// Acquired returns the locks acquired by the statement, with the strongest mode for each relation,
+// in the order the relations appear in the statement. Indexes are resolved to their table using the catalog,
+// which contains the schema before the statement. Relations are identified by their name without the schema qualifier.
+func Acquired(node *pg_query.Node, cat *catalog.Catalog) []Lock {
+	if cat == nil {
+		cat = catalog.New()
+	}
+	var acquired lockList
+	indexTable := func(name string) string {
+		if index, ok := cat.Index(name); ok {
+			return index.Table
+		}
+		return name
+	}
+	switch {
+	case node.GetSelectStmt() != nil:
+		selectStmt := node.GetSelectStmt()
+		mode := AccessShare
+		if len(selectStmt.GetLockingClause()) > 0 {
+			mode = RowShare
+		}
+		acquired.addFromClause(selectStmt.GetFromClause(), mode)
+	case node.GetInsertStmt() != nil:
+		acquired.add(node.GetInsertStmt().GetRelation().GetRelname(), RowExclusive)
+		acquired.addFromClause(node.GetInsertStmt().GetSelectStmt().GetSelectStmt().GetFromClause(), AccessShare)
+	case node.GetUpdateStmt() != nil:
+		acquired.add(node.GetUpdateStmt().GetRelation().GetRelname(), RowExclusive)
+		acquired.addFromClause(node.GetUpdateStmt().GetFromClause(), AccessShare)
+	case node.GetDeleteStmt() != nil:
+		acquired.add(node.GetDeleteStmt().GetRelation().GetRelname(), RowExclusive)
+		acquired.addFromClause(node.GetDeleteStmt().GetUsingClause(), AccessShare)
+	case node.GetCopyStmt() != nil:
+		mode := AccessShare
+		if node.GetCopyStmt().GetIsFrom() {
+			mode = RowExclusive
+		}
+		acquired.add(node.GetCopyStmt().GetRelation().GetRelname(), mode)
+	case node.GetLockStmt() != nil:
+		for _, relation := range node.GetLockStmt().GetRelations() {
+			acquired.add(relation.GetRangeVar().GetRelname(), Mode(node.GetLockStmt().GetMode()))
+		}
+	case node.GetCreateStmt() != nil:
+		createStmt := node.GetCreateStmt()
+		if createStmt.GetPartbound() != nil {
+			for _, parent := range createStmt.GetInhRelations() {
+				acquired.add(parent.GetRangeVar().GetRelname(), AccessExclusive)
+			}
+		}
+		for _, elt := range createStmt.GetTableElts() {
+			acquired.addReferencedTables(elt)
+		}
+	case node.GetIndexStmt() != nil:
+		mode := Share
+		if node.GetIndexStmt().GetConcurrent() {
+			mode = ShareUpdateExclusive
+		}
+		acquired.add(node.GetIndexStmt().GetRelation().GetRelname(), mode)
+	case node.GetAlterTableStmt() != nil:
+		acquired.addAlterTable(node.GetAlterTableStmt(), cat)
+	case node.GetRenameStmt() != nil:
+		if relation := node.GetRenameStmt().GetRelation(); relation != nil {
+			acquired.add(relation.GetRelname(), AccessExclusive)
+		}
+	case node.GetDropStmt() != nil:
+		acquired.addDrop(node.GetDropStmt(), cat)
+	case node.GetTruncateStmt() != nil:
+		for _, relation := range node.GetTruncateStmt().GetRelations() {
+			acquired.add(relation.GetRangeVar().GetRelname(), AccessExclusive)
+		}
+	case node.GetCreateTrigStmt() != nil:
+		acquired.add(node.GetCreateTrigStmt().GetRelation().GetRelname(), ShareRowExclusive)
+	case node.GetClusterStmt() != nil:
+		if relation := node.GetClusterStmt().GetRelation(); relation != nil {
+			acquired.add(relation.GetRelname(), AccessExclusive)
+		}
+	case node.GetReindexStmt() != nil:
+		reindexStmt := node.GetReindexStmt()
+		mode := Share
+		if HasOption(reindexStmt.GetParams(), "concurrently") {
+			mode = ShareUpdateExclusive
+		}
+		switch reindexStmt.GetKind() {
+		case pg_query.ReindexObjectType_REINDEX_OBJECT_TABLE:
+			acquired.add(reindexStmt.GetRelation().GetRelname(), mode)
+		case pg_query.ReindexObjectType_REINDEX_OBJECT_INDEX:
+			acquired.add(indexTable(reindexStmt.GetRelation().GetRelname()), mode)
+			if mode == Share {
+				acquired.add(reindexStmt.GetRelation().GetRelname(), AccessExclusive)
+			}
+		}
+	case node.GetVacuumStmt() != nil:
+		mode := ShareUpdateExclusive
+		if HasOption(node.GetVacuumStmt().GetOptions(), "full") {
+			mode = AccessExclusive
+		}
+		for _, relation := range node.GetVacuumStmt().GetRels() {
+			acquired.add(relation.GetVacuumRelation().GetRelation().GetRelname(), mode)
+		}
+	case node.GetRefreshMatViewStmt() != nil:
+		mode := AccessExclusive
+		if node.GetRefreshMatViewStmt().GetConcurrent() {
+			mode = Exclusive
+		}
+		acquired.add(node.GetRefreshMatViewStmt().GetRelation().GetRelname(), mode)
+	}
+	return acquired
+}
+
+// lockList is a list of locks with a single lock for each relation.
+type lockList []Lock
+
+// add adds a lock on the relation, unless a lock with a stronger mode has already been added.
+func (l *lockList) add(relation string, mode Mode) {
+	if relation == "" {
+		return
+	}
+	for i, lock := range *l {
+		if lock.Relation == relation {
+			if mode > lock.Mode {
+				(*l)[i].Mode = mode
+			}
+			return
+		}
+	}
+	*l = append(*l, Lock{Relation: relation, Mode: mode})
+}
+
+// addFromClause adds the tables of a FROM clause, including joined tables. Tables of subqueries are not included.
+func (l *lockList) addFromClause(items []*pg_query.Node, mode Mode) {
+	for _, item := range items {
+		switch {
+		case item.GetRangeVar() != nil:
+			l.add(item.GetRangeVar().GetRelname(), mode)
+		case item.GetJoinExpr() != nil:
+			l.addFromClause([]*pg_query.Node{item.GetJoinExpr().GetLarg(), item.GetJoinExpr().GetRarg()}, mode)
+		}
+	}
+}
+
+// addReferencedTables adds the tables referenced by the foreign keys of a column definition or table constraint.
+// Adding a foreign key creates triggers on the referenced table.
+func (l *lockList) addReferencedTables(elt *pg_query.Node) {
+	constraints := []*pg_query.Node{elt}
+	if columnDef := elt.GetColumnDef(); columnDef != nil {
+		constraints = columnDef.GetConstraints()
+	}
+	for _, c := range constraints {
+		if constraint := c.GetConstraint(); constraint.GetContype() == pg_query.ConstrType_CONSTR_FOREIGN {
+			l.add(constraint.GetPktable().GetRelname(), ShareRowExclusive)
+		}
+	}
+}
+
+// https://www.postgresql.org/docs/current/sql-altertable.html
+func (l *lockList) addAlterTable(alterTableStmt *pg_query.AlterTableStmt, cat *catalog.Catalog) {
+	relation := alterTableStmt.GetRelation().GetRelname()
+	// The lock is acquired on the relation, even when it is the table of an altered index
+	for _, cmd := range alterTableStmt.GetCmds() {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		l.add(relation, alterTableCmdMode(alterTableCmd))
+		switch alterTableCmd.GetSubtype() {
+		case pg_query.AlterTableType_AT_AddColumn, pg_query.AlterTableType_AT_AddConstraint:
+			l.addReferencedTables(alterTableCmd.GetDef())
+		case pg_query.AlterTableType_AT_AttachPartition:
+			l.add(alterTableCmd.GetDef().GetPartitionCmd().GetName().GetRelname(), AccessExclusive)
+		case pg_query.AlterTableType_AT_DetachPartition:
+			mode := AccessExclusive
+			if alterTableCmd.GetDef().GetPartitionCmd().GetConcurrent() {
+				mode = ShareUpdateExclusive
+			}
+			l.add(alterTableCmd.GetDef().GetPartitionCmd().GetName().GetRelname(), mode)
+		case pg_query.AlterTableType_AT_DropConstraint:
+			// Dropping a foreign key removes the triggers of the referenced table
+			if table, ok := cat.Table(relation); ok {
+				for _, constraint := range table.Constraints {
+					if constraint.Name == alterTableCmd.GetName() && constraint.Type == catalog.ForeignKey {
+						l.add(constraint.RefTable, AccessExclusive)
+					}
+				}
+			}
+		}
+	}
+}
+
+// alterTableCmdMode returns the lock mode acquired by an ALTER TABLE subcommand on the altered relation.
+func alterTableCmdMode(cmd *pg_query.AlterTableCmd) Mode {
+	switch cmd.GetSubtype() {
+	case pg_query.AlterTableType_AT_SetStatistics,
+		pg_query.AlterTableType_AT_SetOptions,
+		pg_query.AlterTableType_AT_ResetOptions,
+		pg_query.AlterTableType_AT_SetRelOptions,
+		pg_query.AlterTableType_AT_ResetRelOptions,
+		pg_query.AlterTableType_AT_ValidateConstraint,
+		pg_query.AlterTableType_AT_ClusterOn,
+		pg_query.AlterTableType_AT_DropCluster,
+		pg_query.AlterTableType_AT_AttachPartition,
+		pg_query.AlterTableType_AT_DetachPartitionFinalize:
+		return ShareUpdateExclusive
+	case pg_query.AlterTableType_AT_EnableTrig,
+		pg_query.AlterTableType_AT_EnableAlwaysTrig,
+		pg_query.AlterTableType_AT_EnableReplicaTrig,
+		pg_query.AlterTableType_AT_DisableTrig,
+		pg_query.AlterTableType_AT_EnableTrigAll,
+		pg_query.AlterTableType_AT_DisableTrigAll,
+		pg_query.AlterTableType_AT_EnableTrigUser,
+		pg_query.AlterTableType_AT_DisableTrigUser:
+		return ShareRowExclusive
+	case pg_query.AlterTableType_AT_AddConstraint:
+		if cmd.GetDef().GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_FOREIGN {
+			return ShareRowExclusive
+		}
+	case pg_query.AlterTableType_AT_DetachPartition:
+		if cmd.GetDef().GetPartitionCmd().GetConcurrent() {
+			return ShareUpdateExclusive
+		}
+	}
+	return AccessExclusive
+}
+
+func (l *lockList) addDrop(dropStmt *pg_query.DropStmt, cat *catalog.Catalog) {
+	for _, object := range dropStmt.GetObjects() {
+		items := object.GetList().GetItems()
+		if len(items) == 0 {
+			continue
+		}
+		name := items[len(items)-1].GetString_().GetSval()
+		switch dropStmt.GetRemoveType() {
+		case pg_query.ObjectType_OBJECT_TABLE:
+			l.add(name, AccessExclusive)
+			if table, ok := cat.Table(name); ok {
+				for _, constraint := range table.Constraints {
+					if constraint.Type == catalog.ForeignKey {
+						l.add(constraint.RefTable, AccessExclusive)
+					}
+				}
+			}
+		case pg_query.ObjectType_OBJECT_VIEW,
+			pg_query.ObjectType_OBJECT_MATVIEW,
+			pg_query.ObjectType_OBJECT_SEQUENCE,
+			pg_query.ObjectType_OBJECT_FOREIGN_TABLE:
+			l.add(name, AccessExclusive)
+		case pg_query.ObjectType_OBJECT_INDEX:
+			table := name
+			if index, ok := cat.Index(name); ok {
+				table = index.Table
+			}
+			if dropStmt.GetConcurrent() {
+				l.add(table, ShareUpdateExclusive)
+			} else {
+				l.add(table, AccessExclusive)
+			}
+		case pg_query.ObjectType_OBJECT_TRIGGER:
+			// The trigger name is qualified with the table name
+			if len(items) > 1 {
+				l.add(items[len(items)-2].GetString_().GetSval(), AccessExclusive)
+			}
+		}
+	}
+}
+
+// HasOption reports whether the option with the given name is enabled in a statement option list,
+// e.g. VACUUM (FULL) or REINDEX (CONCURRENTLY true).
+func HasOption(options []*pg_query.Node, name string) bool {
+	for _, o := range options {
+		defElem := o.GetDefElem()
+		if defElem == nil || defElem.GetDefname() != name {
+			continue
+		}
+		if defElem.GetArg() == nil {
+			return true
+		}
+		switch strings.ToLower(defElem.GetArg().GetString_().GetSval()) {
+		case "false", "off", "0":
+			return false
+		}
+		if i := defElem.GetArg().GetInteger(); i != nil && i.GetIval() == 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
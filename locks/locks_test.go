@@ -0,0 +1,224 @@
+package locks
+
+import (
+	"strings"
+	"testing"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgsafemigrate/catalog"
+)
+
+func parse(t *testing.T, sql string) []*pg_query.Node {
+	t.Helper()
+
+	var nodes []*pg_query.Node
+	for _, statement := range strings.Split(sql, "\n") {
+		tree, err := pg_query.Parse(statement)
+		require.NoError(t, err)
+		for _, s := range tree.GetStmts() {
+			nodes = append(nodes, s.GetStmt())
+		}
+	}
+	return nodes
+}
+
+func TestMode_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, AccessExclusive.Conflicts(AccessShare))
+	assert.False(t, Exclusive.Conflicts(AccessShare))
+	assert.True(t, ShareUpdateExclusive.Conflicts(ShareUpdateExclusive))
+	assert.False(t, Share.Conflicts(Share))
+	assert.False(t, RowExclusive.Conflicts(RowExclusive))
+	for mode := AccessShare; mode <= AccessExclusive; mode++ {
+		for other := AccessShare; other <= AccessExclusive; other++ {
+			assert.Equal(t, mode.Conflicts(other), other.Conflicts(mode), "%s and %s", mode, other)
+		}
+	}
+	assert.True(t, AccessExclusive.BlocksReads())
+	assert.True(t, Share.BlocksWrites())
+	assert.False(t, Share.BlocksReads())
+	assert.False(t, ShareUpdateExclusive.BlocksWrites())
+	assert.Equal(t, "SHARE ROW EXCLUSIVE", ShareRowExclusive.String())
+}
+
+func TestAcquired(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		history string
+		sql     string
+		want    []Lock
+	}{
+		{
+			name: "select",
+			sql:  `SELECT * FROM movies JOIN directors ON directors.id = movies.director_id;`,
+			want: []Lock{{"movies", AccessShare}, {"directors", AccessShare}},
+		},
+		{
+			name: "select for update",
+			sql:  `SELECT * FROM movies FOR UPDATE;`,
+			want: []Lock{{"movies", RowShare}},
+		},
+		{
+			name: "insert from select",
+			sql:  `INSERT INTO movies_archive SELECT * FROM movies;`,
+			want: []Lock{{"movies_archive", RowExclusive}, {"movies", AccessShare}},
+		},
+		{
+			name: "update",
+			sql:  `UPDATE movies SET rating = 0 WHERE rating IS NULL;`,
+			want: []Lock{{"movies", RowExclusive}},
+		},
+		{
+			name: "explicit lock",
+			sql:  `LOCK TABLE movies IN SHARE MODE;`,
+			want: []Lock{{"movies", Share}},
+		},
+		{
+			name: "explicit lock without mode",
+			sql:  `LOCK movies;`,
+			want: []Lock{{"movies", AccessExclusive}},
+		},
+		{
+			name: "create table with foreign key",
+			sql:  `CREATE TABLE awards (id bigint PRIMARY KEY, movie_id bigint REFERENCES movies (id));`,
+			want: []Lock{{"movies", ShareRowExclusive}},
+		},
+		{
+			name: "create partition",
+			sql:  `CREATE TABLE measurements_2023 PARTITION OF measurements FOR VALUES FROM ('2023-01-01') TO ('2024-01-01');`,
+			want: []Lock{{"measurements", AccessExclusive}},
+		},
+		{
+			name: "create index",
+			sql:  `CREATE INDEX movies_title_idx ON movies (title);`,
+			want: []Lock{{"movies", Share}},
+		},
+		{
+			name: "create index concurrently",
+			sql:  `CREATE INDEX CONCURRENTLY movies_title_idx ON movies (title);`,
+			want: []Lock{{"movies", ShareUpdateExclusive}},
+		},
+		{
+			name: "add column",
+			sql:  `ALTER TABLE movies ADD COLUMN rating integer;`,
+			want: []Lock{{"movies", AccessExclusive}},
+		},
+		{
+			name: "add foreign key",
+			sql:  `ALTER TABLE movies ADD CONSTRAINT movies_director_id_fkey FOREIGN KEY (director_id) REFERENCES directors (id) NOT VALID;`,
+			want: []Lock{{"movies", ShareRowExclusive}, {"directors", ShareRowExclusive}},
+		},
+		{
+			name: "validate constraint",
+			sql:  `ALTER TABLE movies VALIDATE CONSTRAINT movies_director_id_fkey;`,
+			want: []Lock{{"movies", ShareUpdateExclusive}},
+		},
+		{
+			name: "strongest mode of multiple subcommands",
+			sql:  `ALTER TABLE movies SET (fillfactor = 70), ALTER COLUMN title SET NOT NULL;`,
+			want: []Lock{{"movies", AccessExclusive}},
+		},
+		{
+			name: "drop foreign key",
+			history: `CREATE TABLE directors (id bigint PRIMARY KEY);
+CREATE TABLE movies (id bigint PRIMARY KEY, director_id bigint REFERENCES directors (id));`,
+			sql:  `ALTER TABLE movies DROP CONSTRAINT movies_director_id_fkey;`,
+			want: []Lock{{"movies", AccessExclusive}, {"directors", AccessExclusive}},
+		},
+		{
+			name: "attach partition",
+			sql:  `ALTER TABLE measurements ATTACH PARTITION measurements_2023 FOR VALUES FROM ('2023-01-01') TO ('2024-01-01');`,
+			want: []Lock{{"measurements", ShareUpdateExclusive}, {"measurements_2023", AccessExclusive}},
+		},
+		{
+			name: "detach partition concurrently",
+			sql:  `ALTER TABLE measurements DETACH PARTITION measurements_2023 CONCURRENTLY;`,
+			want: []Lock{{"measurements", ShareUpdateExclusive}, {"measurements_2023", ShareUpdateExclusive}},
+		},
+		{
+			name: "rename column",
+			sql:  `ALTER TABLE movies RENAME COLUMN title TO name;`,
+			want: []Lock{{"movies", AccessExclusive}},
+		},
+		{
+			name: "drop index",
+			history: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);`,
+			sql:  `DROP INDEX movies_title_idx;`,
+			want: []Lock{{"movies", AccessExclusive}},
+		},
+		{
+			name: "drop index concurrently",
+			history: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);`,
+			sql:  `DROP INDEX CONCURRENTLY movies_title_idx;`,
+			want: []Lock{{"movies", ShareUpdateExclusive}},
+		},
+		{
+			name: "drop trigger",
+			sql:  `DROP TRIGGER movies_updated_at ON movies;`,
+			want: []Lock{{"movies", AccessExclusive}},
+		},
+		{
+			name: "truncate",
+			sql:  `TRUNCATE movies, directors;`,
+			want: []Lock{{"movies", AccessExclusive}, {"directors", AccessExclusive}},
+		},
+		{
+			name: "create trigger",
+			sql:  `CREATE TRIGGER movies_updated_at BEFORE UPDATE ON movies FOR EACH ROW EXECUTE FUNCTION touch_updated_at();`,
+			want: []Lock{{"movies", ShareRowExclusive}},
+		},
+		{
+			name: "reindex index",
+			history: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);`,
+			sql:  `REINDEX INDEX movies_title_idx;`,
+			want: []Lock{{"movies", Share}, {"movies_title_idx", AccessExclusive}},
+		},
+		{
+			name: "reindex table concurrently",
+			sql:  `REINDEX TABLE CONCURRENTLY movies;`,
+			want: []Lock{{"movies", ShareUpdateExclusive}},
+		},
+		{
+			name: "vacuum",
+			sql:  `VACUUM ANALYZE movies;`,
+			want: []Lock{{"movies", ShareUpdateExclusive}},
+		},
+		{
+			name: "vacuum full",
+			sql:  `VACUUM FULL movies;`,
+			want: []Lock{{"movies", AccessExclusive}},
+		},
+		{
+			name: "refresh materialized view concurrently",
+			sql:  `REFRESH MATERIALIZED VIEW CONCURRENTLY movie_stats;`,
+			want: []Lock{{"movie_stats", Exclusive}},
+		},
+		{
+			name: "statement without locks",
+			sql:  `SET lock_timeout = '5s';`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cat := catalog.New()
+			if tt.history != "" {
+				for _, node := range parse(t, tt.history) {
+					cat.Apply(node)
+				}
+			}
+			assert.Equal(t, tt.want, Acquired(parse(t, tt.sql)[0], cat))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package locks
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/catalog"
+)
+
+// Statement is a statement of a migration, along with the locks it acquires.
+type Statement struct {
+	SQL   string
+	Node  *pg_query.Node
+	Locks []Lock
+}
+
+// Transaction contains the statements executed in the same transaction.
+// Statements executed outside a transaction are in a transaction of their own.
+type Transaction struct {
+	Statements []Statement
+}
+
+// HeldLock is a lock held from the statement that acquired it until the end of the transaction.
+type HeldLock struct {
+	Lock
+	// From is the index of the statement that acquired the lock.
+	From int
+}
+
+// Held returns the locks held during the transaction, in the order they are acquired.
+// Locks on a relation that is already locked with a mode that conflicts with the same modes, or more, are not included.
+func (t Transaction) Held() []HeldLock {
+	var held []HeldLock
+	covered := func(lock Lock) bool {
+		for _, h := range held {
+			if h.Relation == lock.Relation && h.Mode.covers(lock.Mode) {
+				return true
+			}
+		}
+		return false
+	}
+	for i, statement := range t.Statements {
+		for _, lock := range statement.Locks {
+			if !covered(lock) {
+				held = append(held, HeldLock{Lock: lock, From: i})
+			}
+		}
+	}
+	return held
+}
+
+// Timeline groups the statements into transactions and sets the locks acquired by each statement.
+// When inTransaction is set, all the statements are executed in a single transaction, as sql-migrate does by default.
+// Otherwise, each statement is executed in its own transaction, except for the statements between explicit
+// BEGIN and COMMIT or ROLLBACK statements. The catalog contains the schema before the statements and is not modified.
+func Timeline(statements []Statement, inTransaction bool, cat *catalog.Catalog) []Transaction {
+	if cat == nil {
+		cat = catalog.New()
+	}
+	cat = cat.Clone()
+	var (
+		transactions []Transaction
+		current      *Transaction
+		explicit     bool
+	)
+	for _, statement := range statements {
+		statement.Locks = Acquired(statement.Node, cat)
+		cat.Apply(statement.Node)
+		if current == nil {
+			current = &Transaction{}
+		}
+		current.Statements = append(current.Statements, statement)
+		if inTransaction {
+			continue
+		}
+		switch statement.Node.GetTransactionStmt().GetKind() {
+		case pg_query.TransactionStmtKind_TRANS_STMT_BEGIN, pg_query.TransactionStmtKind_TRANS_STMT_START:
+			explicit = true
+			continue
+		case pg_query.TransactionStmtKind_TRANS_STMT_COMMIT, pg_query.TransactionStmtKind_TRANS_STMT_ROLLBACK:
+			explicit = false
+		}
+		if !explicit {
+			transactions = append(transactions, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		transactions = append(transactions, *current)
+	}
+	return transactions
+}
@@ -0,0 +1,86 @@
+package locks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statements(t *testing.T, sql string) []Statement {
+	t.Helper()
+
+	var statements []Statement
+	for _, node := range parse(t, sql) {
+		statements = append(statements, Statement{Node: node})
+	}
+	return statements
+}
+
+func TestTimeline(t *testing.T) {
+	t.Parallel()
+
+	sql := `SET lock_timeout = '5s';
+ALTER TABLE movies ADD COLUMN rating integer;
+CREATE INDEX movies_rating_idx ON movies (rating);
+UPDATE directors SET name = 'unknown' WHERE name IS NULL;`
+
+	transactions := Timeline(statements(t, sql), true, nil)
+	require.Len(t, transactions, 1)
+	assert.Len(t, transactions[0].Statements, 4)
+	assert.Equal(t, []Lock{{"movies", Share}}, transactions[0].Statements[2].Locks)
+	assert.Equal(t, []HeldLock{
+		{Lock: Lock{"movies", AccessExclusive}, From: 1},
+		{Lock: Lock{"directors", RowExclusive}, From: 3},
+	}, transactions[0].Held())
+
+	transactions = Timeline(statements(t, sql), false, nil)
+	assert.Len(t, transactions, 4)
+}
+
+func TestTimeline_ExplicitTransaction(t *testing.T) {
+	t.Parallel()
+
+	sql := `CREATE INDEX CONCURRENTLY movies_rating_idx ON movies (rating);
+BEGIN;
+ALTER TABLE movies ADD CONSTRAINT movies_rating_check CHECK (rating > 0) NOT VALID;
+ALTER TABLE movies VALIDATE CONSTRAINT movies_rating_check;
+COMMIT;
+VACUUM ANALYZE movies;`
+
+	transactions := Timeline(statements(t, sql), false, nil)
+	require.Len(t, transactions, 3)
+	assert.Len(t, transactions[0].Statements, 1)
+	assert.Len(t, transactions[1].Statements, 4)
+	assert.Equal(t, []HeldLock{{Lock: Lock{"movies", AccessExclusive}, From: 1}}, transactions[1].Held())
+	assert.Len(t, transactions[2].Statements, 1)
+}
+
+func TestTransaction_Held(t *testing.T) {
+	t.Parallel()
+
+	transaction := Transaction{Statements: []Statement{
+		{Locks: []Lock{{"movies", Share}}},
+		{Locks: []Lock{{"movies", AccessShare}, {"directors", ShareRowExclusive}}},
+		{Locks: []Lock{{"movies", RowExclusive}, {"directors", Share}}},
+		{Locks: []Lock{{"movies", AccessExclusive}}},
+	}}
+	assert.Equal(t, []HeldLock{
+		{Lock: Lock{"movies", Share}, From: 0},
+		{Lock: Lock{"directors", ShareRowExclusive}, From: 1},
+		{Lock: Lock{"movies", RowExclusive}, From: 2},
+		{Lock: Lock{"movies", AccessExclusive}, From: 3},
+	}, transaction.Held())
+}
+
+func TestTimeline_Catalog(t *testing.T) {
+	t.Parallel()
+
+	sql := `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);
+DROP INDEX movies_title_idx;`
+
+	transactions := Timeline(statements(t, sql), true, nil)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, []Lock{{"movies", AccessExclusive}}, transactions[0].Statements[2].Locks)
+}
@@ -21,10 +21,54 @@ func main() {
 					"Exits with a non-zero exit code on failure. Migration file paths are given as positional arguments.",
 				Flags: []cli.Flag{
 					cmd.ExcludedRulesFlag(),
+					cmd.EnabledRulesFlag(),
+					cmd.ConfigFlag(),
+					cmd.PostgresVersionFlag(),
+					cmd.SchemaFlag(),
+					cmd.TableHintsFlag(),
 				},
 				Action: func(ctx *cli.Context) error {
+					settings, err := cmd.ConfiguredSettings(cmd.Options{
+						ConfigPath:      ctx.String(cmd.ConfigFlag().Name),
+						EnabledRules:    ctx.StringSlice(cmd.EnabledRulesFlag().Name),
+						ExcludedRules:   ctx.StringSlice(cmd.ExcludedRulesFlag().Name),
+						PostgresVersion: ctx.Int(cmd.PostgresVersionFlag().Name),
+						SchemaPath:      ctx.String(cmd.SchemaFlag().Name),
+						TableHintsPath:  ctx.String(cmd.TableHintsFlag().Name),
+					})
+					if err != nil {
+						return err
+					}
 					// TODO: make reporter configurable
-					return cmd.Check(ctx, ctx.Args().Slice(), ctx.StringSlice(cmd.ExcludedRulesFlag().Name), reporter.PlainText{})
+					return cmd.Check(ctx, ctx.Args().Slice(), settings, reporter.PlainText{})
+				},
+			},
+			{
+				Name:  "locks",
+				Usage: "Print the locks acquired by the statements of migration files",
+				Description: "The locks sub-command prints, for each migration file and direction, the table-level locks " +
+					"acquired by each statement and how long they are held within each transaction. " +
+					"Migration file paths are given as positional arguments.",
+				Flags: []cli.Flag{
+					cmd.SchemaFlag(),
+				},
+				Action: func(ctx *cli.Context) error {
+					return cmd.Locks(ctx, ctx.Args().Slice(), ctx.String(cmd.SchemaFlag().Name))
+				},
+			},
+			{
+				Name:  "roundtrip",
+				Usage: "Simulate applying the migration files up, down and up again",
+				Description: "The roundtrip sub-command applies, for each migration file, the forward migration, the rollback " +
+					"and the forward migration again to an in-memory schema. It reports the differences between the schema " +
+					"before the forward migration and after the rollback, and the statements that would fail when the forward " +
+					"migration is applied again because the objects they create already exist. " +
+					"Exits with a non-zero exit code on failure. Migration file paths are given as positional arguments.",
+				Flags: []cli.Flag{
+					cmd.SchemaFlag(),
+				},
+				Action: func(ctx *cli.Context) error {
+					return cmd.Roundtrip(ctx, ctx.Args().Slice(), ctx.String(cmd.SchemaFlag().Name))
 				},
 			},
 			{
@@ -23,10 +23,32 @@ func TestExecutable_ListRulesCommand(t *testing.T) {
 }
 
 const expectedFailureOutput = `
-Rule high-availability-avoid-non-concurrent-index-creation violation found for statement:
+Rule consistency-up-changes-not-reverted violation found for statement:
+	  ALTER TABLE "recipes" ADD COLUMN "public" boolean NOT NULL, ADD COLUMN "private" boolean;
+	Explanation: Every table, view, column, index, constraint, type, sequence and schema created in the -- +migrate Up section should be dropped in the -- +migrate Down section. Otherwise, rolling back and re-applying the migration fails because the objects already exist.
+	Details: Not dropped in the rollback: column recipes.public, column recipes.private
+
+	Rule high-availability-require-lock-timeout violation found for statement:
+	  ALTER TABLE "recipes" ADD COLUMN "public" boolean NOT NULL, ADD COLUMN "private" boolean;
+	Explanation: Statements that acquire a table lock must be preceded by a SET lock_timeout (or SET LOCAL lock_timeout) statement. While waiting for the lock, all the queries on the table are queued behind the migration, even if the statement itself is instant.
+
+	Rule high-availability-require-lock-timeout violation found for statement:
+	  ALTER TABLE movies ALTER COLUMN "public" SET NOT NULL;
+	Explanation: Statements that acquire a table lock must be preceded by a SET lock_timeout (or SET LOCAL lock_timeout) statement. While waiting for the lock, all the queries on the table are queued behind the migration, even if the statement itself is instant.
+
+	Rule consistency-up-changes-not-reverted violation found for statement:
+	  CREATE INDEX ON films (created_at);
+	Explanation: Every table, view, column, index, constraint, type, sequence and schema created in the -- +migrate Up section should be dropped in the -- +migrate Down section. Otherwise, rolling back and re-applying the migration fails because the objects already exist.
+	Details: Not dropped in the rollback: index films_created_at_idx
+
+	Rule high-availability-avoid-non-concurrent-index-creation violation found for statement:
 	  CREATE INDEX ON films (created_at);
 	Explanation: Non-concurrent index creation will not allow writes while the index is being built.
 
+	Rule high-availability-require-lock-timeout violation found for statement:
+	  CREATE INDEX ON films (created_at);
+	Explanation: Statements that acquire a table lock must be preceded by a SET lock_timeout (or SET LOCAL lock_timeout) statement. While waiting for the lock, all the queries on the table are queued behind the migration, even if the statement itself is instant.
+
 	Rule maintainability-indexes-name-is-required violation found for statement:
 	  CREATE INDEX ON films (created_at);
 	Explanation: Indexes should be explicitly named.
@@ -35,6 +57,15 @@ Rule high-availability-avoid-non-concurrent-index-creation violation found for s
 	  CREATE UNIQUE INDEX title_idx ON films (title) INCLUDE (director, rating);
 	Explanation: Non-concurrent index creation will not allow writes while the index is being built.
 
+	Rule high-availability-require-lock-timeout violation found for statement:
+	  CREATE UNIQUE INDEX title_idx ON films (title) INCLUDE (director, rating);
+	Explanation: Statements that acquire a table lock must be preceded by a SET lock_timeout (or SET LOCAL lock_timeout) statement. While waiting for the lock, all the queries on the table are queued behind the migration, even if the statement itself is instant.
+
+	Rule consistency-up-changes-not-reverted violation found for statement:
+	  CREATE INDEX CONCURRENTLY "email_idx" ON "companies" ("email");
+	Explanation: Every table, view, column, index, constraint, type, sequence and schema created in the -- +migrate Up section should be dropped in the -- +migrate Down section. Otherwise, rolling back and re-applying the migration fails because the objects already exist.
+	Details: Not dropped in the rollback: index email_idx
+
 	Rule transactions-concurrent-index-operation-cannot-be-executed-in-transaction violation found for statement:
 	  CREATE INDEX CONCURRENTLY "email_idx" ON "companies" ("email");
 	Explanation: Concurrent index operations cannot be executed inside a transaction.
@@ -43,6 +74,15 @@ Rule high-availability-avoid-non-concurrent-index-creation violation found for s
 	  BEGIN;
 	Explanation: Nested transactions are not supported in PostgreSQL.
 
+	Rule data-safety-update-or-delete-without-where violation found for statement:
+	  UPDATE films SET updated_at = CURRENT_TIMESTAMP;
+	Explanation: UPDATE or DELETE statements without a WHERE clause affect all table rows in a single transaction, holding row locks until the transaction ends and producing a large volume of WAL. Backfills should be performed in batches.
+
+	Rule transactions-no-ddl-and-dml-mix violation found for statement:
+	  UPDATE films SET updated_at = CURRENT_TIMESTAMP;
+	Explanation: Data modification statements (backfills) executed in the same transaction as DDL statements that acquire an ACCESS EXCLUSIVE lock keep the table locked until the backfill completes. Run backfills in a separate migration.
+	Details: ACCESS EXCLUSIVE lock acquired in the same transaction by: ALTER TABLE recipes ADD COLUMN public boolean NOT NULL, ADD COLUMN private boolean; ALTER TABLE movies ALTER COLUMN public SET NOT NULL
+
 	Rule transactions-no-nested-transactions violation found for statement:
 	  COMMIT;
 	Explanation: Nested transactions are not supported in PostgreSQL.
@@ -51,9 +91,17 @@ Rule high-availability-avoid-non-concurrent-index-creation violation found for s
 	  ALTER TABLE "movies" RENAME TO "movies_old";
 	Explanation: Renaming a table can cause errors in previous application versions.
 
+	Rule high-availability-require-lock-timeout violation found for statement:
+	  ALTER TABLE "movies" RENAME TO "movies_old";
+	Explanation: Statements that acquire a table lock must be preceded by a SET lock_timeout (or SET LOCAL lock_timeout) statement. While waiting for the lock, all the queries on the table are queued behind the migration, even if the statement itself is instant.
+
 	Rule high-availability-avoid-non-concurrent-index-drop violation found for statement:
 	  DROP INDEX IF EXISTS title_idx;
 	Explanation: Non-concurrent index drop will not allow writes while the index is being built.
+
+	Rule high-availability-require-lock-timeout violation found for statement:
+	  DROP INDEX IF EXISTS title_idx;
+	Explanation: Statements that acquire a table lock must be preceded by a SET lock_timeout (or SET LOCAL lock_timeout) statement. While waiting for the lock, all the queries on the table are queued behind the migration, even if the statement itself is instant.
 ❌ Problems found.
 exit status 1`
 
@@ -106,3 +154,36 @@ func TestExecutable_CheckCommand_NoProblemsFound(t *testing.T) {
 	assert.NotEmpty(t, output)
 	assert.Equal(t, "\n✓ No problems found!\n", string(output))
 }
+
+func TestExecutable_LocksCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("go", "run", "./main.go", "locks", "./testdata/sql/20230930091220-add-index.sql")
+	output, err := cmd.CombinedOutput()
+
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "[10] ALTER TABLE \"movies\" RENAME TO \"movies_old\";\n        ACCESS EXCLUSIVE on movies (blocks reads and writes)")
+	assert.Contains(t, string(output), "ACCESS EXCLUSIVE on recipes, from [1] to [10]")
+	assert.Contains(t, string(output), "SHARE UPDATE EXCLUSIVE on companies, from [5] to [10]")
+}
+
+func TestExecutable_RoundtripCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("go", "run", "./main.go", "roundtrip", "./testdata/sql/20230930091220-add-index.sql")
+	output, err := cmd.CombinedOutput()
+
+	require.Error(t, err)
+	assert.Contains(t, string(output), "  Schema differences after Up and Down:\n    index email_idx: added\n    index films_created_at_idx: added\n")
+	assert.Contains(t, string(output), "    [5] CREATE INDEX CONCURRENTLY \"email_idx\" ON \"companies\" (\"email\");\n        already exists: index email_idx\n")
+}
+
+func TestExecutable_RoundtripCommand_NoProblems(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("go", "run", "./main.go", "roundtrip", "./testdata/sql/20231013091220-add-index-success.sql")
+	output, err := cmd.CombinedOutput()
+
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "No differences after Up and Down")
+}
@@ -71,10 +71,18 @@ func (p PlainText) Print(reports []Report) string {
 			}
 			hasFailure = true
 			for _, e := range r.Errors.Sorted() {
-				fileOutput.WriteString(fmt.Sprintf("\tRule %s violation found for statement:\n\t  %s\n\tExplanation: %s\n\n",
+				fileOutput.WriteString(fmt.Sprintf("\tRule %s violation found for statement:\n\t  %s\n\tExplanation: %s\n",
 					e.Alias(),
 					strings.TrimSpace(e.Statement()),
 					e.Documentation()))
+				// Warning is the default severity, which is not printed
+				if e.Severity() != rules.SeverityWarning {
+					fileOutput.WriteString(fmt.Sprintf("\tSeverity: %s\n", e.Severity()))
+				}
+				if details := e.Details(); details != "" {
+					fileOutput.WriteString(fmt.Sprintf("\tDetails: %s\n", details))
+				}
+				fileOutput.WriteString("\n")
 			}
 		}
 		if hasFailure {
@@ -2,6 +2,7 @@ package reporter
 
 import (
 	"github.com/stretchr/testify/assert"
+	"pgsafemigrate/rules"
 	"testing"
 )
 
@@ -9,11 +10,17 @@ type mockError struct {
 	alias         string
 	statement     string
 	documentation string
+	details       string
+	severity      rules.Severity
 }
 
 func (m mockError) Alias() string         { return m.alias }
 func (m mockError) Documentation() string { return m.documentation }
 func (m mockError) Statement() string     { return m.statement }
+func (m mockError) Details() string       { return m.details }
+func (m mockError) Severity() rules.Severity {
+	return m.severity
+}
 
 func TestPlainText_Print(t *testing.T) {
 	t.Parallel()
@@ -60,7 +67,7 @@ func TestPlainText_Print(t *testing.T) {
 				{
 					FilePath: "sql/migration-2.sql",
 					Errors: ValidationErrors{
-						mockError{alias: "test-rule-2", statement: "SELECT 4", documentation: "test docs #4"},
+						mockError{alias: "test-rule-2", statement: "SELECT 4", documentation: "test docs #4", details: "test details #4"},
 					},
 				},
 			},
@@ -72,7 +79,24 @@ func TestPlainText_Print(t *testing.T) {
 				"\tRule test-rule-2 violation found for statement:\n\t  SELECT 3\n" +
 				"\tExplanation: test docs #3\n\nFile sql/migration-2.sql Results:\n" +
 				"\tRule test-rule-2 violation found for statement:\n\t  SELECT 4\n" +
-				"\tExplanation: test docs #4",
+				"\tExplanation: test docs #4\n\tDetails: test details #4",
+		},
+		{
+			name: "errors with severity",
+			reports: []Report{
+				{
+					FilePath: "sql/migration-1.sql",
+					Errors: ValidationErrors{
+						mockError{alias: "test-rule-1", statement: "SELECT 1", documentation: "test docs #1", severity: rules.SeverityInfo},
+						mockError{alias: "test-rule-2", statement: "SELECT 2", documentation: "test docs #2", severity: rules.SeverityError},
+					},
+				},
+			},
+			want: "File sql/migration-1.sql Results:\n" +
+				"\tRule test-rule-1 violation found for statement:\n\t  SELECT 1\n" +
+				"\tExplanation: test docs #1\n\tSeverity: info\n\n" +
+				"\tRule test-rule-2 violation found for statement:\n\t  SELECT 2\n" +
+				"\tExplanation: test docs #2\n\tSeverity: error",
 		},
 	}
 	for _, tt := range tests {
@@ -0,0 +1,358 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	migrate "github.com/rubenv/sql-migrate"
+	"pgsafemigrate/catalog"
+)
+
+// IrreversibleMigration - Migrations without a rollback cannot be reverted when a deployment fails.
+type IrreversibleMigration struct{}
+
+func (r IrreversibleMigration) Alias() string {
+	return ConsistencyRule("irreversible-migration")
+}
+
+func (r IrreversibleMigration) Documentation() string {
+	return "The migration has no statements in its -- +migrate Down section, so it cannot be rolled back. " +
+		"Add the statements that revert the forward migration."
+}
+
+// Process reports the first statement of the forward migration when the rollback is missing or empty.
+// Plain SQL scripts without sql-migrate annotations have no rollback and are not reported.
+func (r IrreversibleMigration) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if ctx.Direction != migrate.Up || !ctx.Annotated || len(ctx.OppositeStatements) > 0 {
+		return false
+	}
+	return len(ctx.AllStatements) > 0 && ctx.AllStatements[0] == node
+}
+
+// UpChangesNotReverted - Objects created by the forward migration should be dropped by the rollback.
+type UpChangesNotReverted struct{}
+
+func (r UpChangesNotReverted) Alias() string {
+	return ConsistencyRule("up-changes-not-reverted")
+}
+
+func (r UpChangesNotReverted) Documentation() string {
+	return "Every table, view, column, index, constraint, type, sequence and schema created in the -- +migrate Up section " +
+		"should be dropped in the -- +migrate Down section. Otherwise, rolling back and re-applying the migration fails " +
+		"because the objects already exist."
+}
+
+func (r UpChangesNotReverted) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	return len(r.notReverted(node, ctx)) > 0
+}
+
+func (r UpChangesNotReverted) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	return "Not dropped in the rollback: " + joinObjects(r.notReverted(node, ctx))
+}
+
+// notReverted returns the objects created by the statement of the forward migration that the rollback does not drop.
+// Migrations without a rollback are reported by IrreversibleMigration instead.
+func (r UpChangesNotReverted) notReverted(node *pg_query.Node, ctx MigrationContext) []schemaObject {
+	if ctx.Direction != migrate.Up || len(ctx.OppositeStatements) == 0 {
+		return nil
+	}
+	cache := consistencyOf(ctx)
+	var objects []schemaObject
+	for _, created := range cache.created[node] {
+		if !revertedBy(created, cache.oppositeDropped) {
+			objects = append(objects, created)
+		}
+	}
+	return objects
+}
+
+// DownDropsUnrelatedObject - The rollback should only drop objects created by the forward migration.
+type DownDropsUnrelatedObject struct{}
+
+func (r DownDropsUnrelatedObject) Alias() string {
+	return ConsistencyRule("down-drops-unrelated-objects")
+}
+
+func (r DownDropsUnrelatedObject) Documentation() string {
+	return "The -- +migrate Down section drops objects that the -- +migrate Up section did not create. " +
+		"Rolling back the migration would then destroy data or schema that existed before it."
+}
+
+func (r DownDropsUnrelatedObject) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	return len(r.unrelated(node, ctx)) > 0
+}
+
+func (r DownDropsUnrelatedObject) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	return "Not created in the forward migration: " + joinObjects(r.unrelated(node, ctx))
+}
+
+// unrelated returns the objects dropped by the statement of the rollback that the forward migration does not create.
+func (r DownDropsUnrelatedObject) unrelated(node *pg_query.Node, ctx MigrationContext) []schemaObject {
+	if ctx.Direction != migrate.Down {
+		return nil
+	}
+	var objects []schemaObject
+	for _, dropped := range droppedObjects(node) {
+		var related bool
+		for _, c := range consistencyOf(ctx).oppositeCreated {
+			if droppedFrom(dropped, c) {
+				related = true
+				break
+			}
+		}
+		if !related {
+			objects = append(objects, dropped)
+		}
+	}
+	return objects
+}
+
+// consistencyOf returns the cache of the section, with the objects created by the forward migration and dropped by the rollback.
+// They are derived once per section, since each statement is compared with all the statements of the other direction.
+func consistencyOf(ctx MigrationContext) *sectionCache {
+	cache := ctx.sections()
+	cache.consistencyOnce.Do(func() {
+		if ctx.Direction == migrate.Down {
+			// The forward migration is executed on the schema before the migration
+			cat := catalog.New()
+			if ctx.OppositeCatalog != nil {
+				cat = ctx.OppositeCatalog.Clone()
+			}
+			for _, n := range ctx.OppositeStatements {
+				cache.oppositeCreated = append(cache.oppositeCreated, createdObjects(n, cat)...)
+			}
+			return
+		}
+		cat := catalog.New()
+		if ctx.Catalog != nil {
+			cat = ctx.Catalog.Clone()
+		}
+		cache.created = make(map[*pg_query.Node][]schemaObject, len(ctx.AllStatements))
+		for _, n := range ctx.AllStatements {
+			cache.created[n] = createdObjects(n, cat)
+		}
+		for _, n := range ctx.OppositeStatements {
+			cache.oppositeDropped = append(cache.oppositeDropped, droppedObjects(n)...)
+		}
+	})
+	return cache
+}
+
+// schemaObject is an object created or dropped by a statement. Columns and constraints belong to a table,
+// and constraints created without a name have an empty name, since it cannot always be determined.
+type schemaObject struct {
+	kind  string
+	table string
+	name  string
+}
+
+func (o schemaObject) String() string {
+	switch {
+	case o.kind == "constraint" && o.name == "":
+		return fmt.Sprintf("unnamed constraint on %s", o.table)
+	case o.kind == "column" || o.kind == "constraint":
+		return fmt.Sprintf("%s %s.%s", o.kind, o.table, o.name)
+	}
+	return fmt.Sprintf("%s %s", o.kind, o.name)
+}
+
+func joinObjects(objects []schemaObject) string {
+	var names []string
+	for _, o := range objects {
+		names = append(names, o.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// revertedBy reports whether any of the dropped objects reverts the created object. Columns, constraints and indexes
+// are also reverted by dropping their table, and an unnamed constraint by dropping any constraint of its table.
+func revertedBy(created schemaObject, dropped []schemaObject) bool {
+	for _, d := range dropped {
+		switch {
+		case d.kind == "table" && created.table != "" && d.name == created.table:
+			return true
+		case d.kind != created.kind:
+			continue
+		case created.kind == "constraint":
+			if d.table == created.table && (created.name == "" || d.name == created.name) {
+				return true
+			}
+		case created.kind == "column":
+			if d.table == created.table && d.name == created.name {
+				return true
+			}
+		case d.name == created.name:
+			return true
+		}
+	}
+	return false
+}
+
+// droppedFrom reports whether the dropped object is the created object or a part of it, e.g. a column of a created table.
+func droppedFrom(dropped, created schemaObject) bool {
+	if created.kind == "table" && dropped.table == created.name {
+		return true
+	}
+	// Dropping a table is not related to the columns, constraints and indexes added to it
+	if dropped.kind == "table" && created.kind != "table" {
+		return false
+	}
+	return revertedBy(created, []schemaObject{dropped})
+}
+
+// createdObjects returns the objects created by the statement and applies it to the catalog, which contains the schema
+// before the statement. The catalog is used to determine the names that PostgreSQL assigns to indexes and constraints
+// created without a name.
+func createdObjects(node *pg_query.Node, cat *catalog.Catalog) []schemaObject {
+	switch {
+	case node.GetIndexStmt() != nil:
+		return indexCreatedObjects(node, cat)
+	case node.GetAlterTableStmt() != nil:
+		return alterTableCreatedObjects(node, cat)
+	}
+	cat.Apply(node)
+	switch {
+	case node.GetCreateStmt() != nil:
+		return []schemaObject{{kind: "table", name: node.GetCreateStmt().GetRelation().GetRelname()}}
+	case node.GetCreateTableAsStmt() != nil:
+		createStmt := node.GetCreateTableAsStmt()
+		kind := "table"
+		if createStmt.GetObjtype() == pg_query.ObjectType_OBJECT_MATVIEW {
+			kind = "materialized view"
+		}
+		return []schemaObject{{kind: kind, name: createStmt.GetInto().GetRel().GetRelname()}}
+	case node.GetViewStmt() != nil:
+		return []schemaObject{{kind: "view", name: node.GetViewStmt().GetView().GetRelname()}}
+	case node.GetCreateEnumStmt() != nil:
+		return []schemaObject{{kind: "type", name: lastName(node.GetCreateEnumStmt().GetTypeName())}}
+	case node.GetCompositeTypeStmt() != nil:
+		return []schemaObject{{kind: "type", name: node.GetCompositeTypeStmt().GetTypevar().GetRelname()}}
+	case node.GetCreateDomainStmt() != nil:
+		return []schemaObject{{kind: "type", name: lastName(node.GetCreateDomainStmt().GetDomainname())}}
+	case node.GetCreateSeqStmt() != nil:
+		return []schemaObject{{kind: "sequence", name: node.GetCreateSeqStmt().GetSequence().GetRelname()}}
+	case node.GetCreateSchemaStmt() != nil:
+		return []schemaObject{{kind: "schema", name: node.GetCreateSchemaStmt().GetSchemaname()}}
+	}
+	return nil
+}
+
+// indexCreatedObjects returns the index created by the statement, which is applied to the catalog
+// to determine the default name of unnamed indexes, with its numeric suffix when the name is already used.
+func indexCreatedObjects(node *pg_query.Node, cat *catalog.Catalog) []schemaObject {
+	table := node.GetIndexStmt().GetRelation().GetRelname()
+	name := node.GetIndexStmt().GetIdxname()
+	if name != "" {
+		cat.Apply(node)
+		return []schemaObject{{kind: "index", table: table, name: name}}
+	}
+	existing := make(map[string]bool)
+	for _, index := range cat.Indexes(table) {
+		existing[index.Name] = true
+	}
+	cat.Apply(node)
+	for _, index := range cat.Indexes(table) {
+		if !existing[index.Name] {
+			name = index.Name
+		}
+	}
+	return []schemaObject{{kind: "index", table: table, name: name}}
+}
+
+func alterTableCreatedObjects(node *pg_query.Node, cat *catalog.Catalog) []schemaObject {
+	table := node.GetAlterTableStmt().GetRelation().GetRelname()
+	var objects []schemaObject
+	for _, cmd := range node.GetAlterTableStmt().GetCmds() {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		switch alterTableCmd.GetSubtype() {
+		case pg_query.AlterTableType_AT_AddColumn:
+			objects = append(objects, schemaObject{kind: "column", table: table, name: alterTableCmd.GetDef().GetColumnDef().GetColname()})
+		case pg_query.AlterTableType_AT_AddConstraint:
+			constraint := alterTableCmd.GetDef().GetConstraint()
+			switch constraint.GetContype() {
+			case pg_query.ConstrType_CONSTR_PRIMARY,
+				pg_query.ConstrType_CONSTR_UNIQUE,
+				pg_query.ConstrType_CONSTR_FOREIGN,
+				pg_query.ConstrType_CONSTR_CHECK,
+				pg_query.ConstrType_CONSTR_EXCLUSION:
+				objects = append(objects, schemaObject{kind: "constraint", table: table, name: constraint.GetConname()})
+			}
+		}
+	}
+	// Unnamed constraints are assigned their default name when the table is known
+	t, ok := cat.Table(table)
+	if !ok {
+		cat.Apply(node)
+		return objects
+	}
+	existing := make(map[string]bool)
+	for _, c := range t.Constraints {
+		existing[c.Name] = true
+	}
+	cat.Apply(node)
+	var added []string
+	for _, c := range t.Constraints {
+		if !existing[c.Name] {
+			added = append(added, c.Name)
+		}
+	}
+	for i := range objects {
+		if objects[i].kind == "constraint" && objects[i].name == "" && len(added) > 0 {
+			objects[i].name, added = added[0], added[1:]
+		}
+	}
+	return objects
+}
+
+// droppedObjects returns the objects dropped by the statement.
+func droppedObjects(node *pg_query.Node) []schemaObject {
+	var objects []schemaObject
+	if dropStmt := node.GetDropStmt(); dropStmt != nil {
+		kind := map[pg_query.ObjectType]string{
+			pg_query.ObjectType_OBJECT_TABLE:    "table",
+			pg_query.ObjectType_OBJECT_VIEW:     "view",
+			pg_query.ObjectType_OBJECT_MATVIEW:  "materialized view",
+			pg_query.ObjectType_OBJECT_INDEX:    "index",
+			pg_query.ObjectType_OBJECT_TYPE:     "type",
+			pg_query.ObjectType_OBJECT_DOMAIN:   "type",
+			pg_query.ObjectType_OBJECT_SEQUENCE: "sequence",
+			pg_query.ObjectType_OBJECT_SCHEMA:   "schema",
+		}[dropStmt.GetRemoveType()]
+		if kind == "" {
+			return nil
+		}
+		for _, object := range dropStmt.GetObjects() {
+			var name string
+			switch {
+			case object.GetList() != nil:
+				name = lastName(object.GetList().GetItems())
+			case object.GetTypeName() != nil:
+				name = lastName(object.GetTypeName().GetNames())
+			default:
+				name = object.GetString_().GetSval()
+			}
+			objects = append(objects, schemaObject{kind: kind, name: name})
+		}
+	}
+	if alterTableStmt := node.GetAlterTableStmt(); alterTableStmt != nil {
+		table := alterTableStmt.GetRelation().GetRelname()
+		for _, cmd := range alterTableStmt.GetCmds() {
+			alterTableCmd := cmd.GetAlterTableCmd()
+			switch alterTableCmd.GetSubtype() {
+			case pg_query.AlterTableType_AT_DropColumn:
+				objects = append(objects, schemaObject{kind: "column", table: table, name: alterTableCmd.GetName()})
+			case pg_query.AlterTableType_AT_DropConstraint:
+				objects = append(objects, schemaObject{kind: "constraint", table: table, name: alterTableCmd.GetName()})
+			}
+		}
+	}
+	return objects
+}
+
+func lastName(nodes []*pg_query.Node) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	return nodes[len(nodes)-1].GetString_().GetSval()
+}
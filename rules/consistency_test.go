@@ -0,0 +1,322 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// parseSection returns the statements of a migration section, given one statement per line.
+func parseSection(t *testing.T, sql string) []*pg_query.Node {
+	t.Helper()
+
+	var nodes []*pg_query.Node
+	for _, statement := range strings.Split(sql, "\n") {
+		if statement != "" {
+			nodes = append(nodes, parseStatement(t, statement))
+		}
+	}
+	return nodes
+}
+
+func TestIrreversibleMigration_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		up        string
+		down      string
+		annotated bool
+		want      bool
+	}{
+		{
+			name:      "empty rollback",
+			up:        `CREATE TABLE reviews (id bigint PRIMARY KEY);`,
+			annotated: true,
+			want:      true,
+		},
+		{
+			name:      "rollback",
+			up:        `CREATE TABLE reviews (id bigint PRIMARY KEY);`,
+			down:      `DROP TABLE reviews;`,
+			annotated: true,
+			want:      false,
+		},
+		{
+			name: "plain SQL script",
+			up:   `CREATE TABLE reviews (id bigint PRIMARY KEY);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			up := parseSection(t, tt.up)
+			ctx := MigrationContext{
+				AllStatements:      up,
+				Direction:          migrate.Up,
+				Annotated:          tt.annotated,
+				OppositeStatements: parseSection(t, tt.down),
+			}
+			assert.Equal(t, tt.want, IrreversibleMigration{}.Process(up[0], ctx))
+		})
+	}
+}
+
+func TestIrreversibleMigration_Process_FirstStatementOnly(t *testing.T) {
+	t.Parallel()
+
+	up := parseSection(t, `CREATE TABLE reviews (id bigint PRIMARY KEY);
+CREATE INDEX reviews_id_idx ON reviews (id);`)
+	ctx := MigrationContext{AllStatements: up, Direction: migrate.Up, Annotated: true}
+	assert.True(t, IrreversibleMigration{}.Process(up[0], ctx))
+	assert.False(t, IrreversibleMigration{}.Process(up[1], ctx))
+}
+
+func TestUpChangesNotReverted_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		up          string
+		down        string
+		want        bool
+		wantExplain string
+	}{
+		{
+			name: "table dropped",
+			up:   `CREATE TABLE reviews (id bigint PRIMARY KEY, movie_id bigint REFERENCES movies (id));`,
+			down: `DROP TABLE IF EXISTS reviews;`,
+			want: false,
+		},
+		{
+			name:        "table not dropped",
+			up:          `CREATE TABLE reviews (id bigint PRIMARY KEY);`,
+			down:        `DROP TABLE ratings;`,
+			want:        true,
+			wantExplain: "Not dropped in the rollback: table reviews",
+		},
+		{
+			name: "columns dropped",
+			up:   `ALTER TABLE movies ADD COLUMN rating integer, ADD COLUMN budget integer;`,
+			down: `ALTER TABLE movies DROP COLUMN rating, DROP COLUMN budget;`,
+			want: false,
+		},
+		{
+			name:        "column not dropped",
+			up:          `ALTER TABLE movies ADD COLUMN rating integer, ADD COLUMN budget integer;`,
+			down:        `ALTER TABLE movies DROP COLUMN rating;`,
+			want:        true,
+			wantExplain: "Not dropped in the rollback: column movies.budget",
+		},
+		{
+			name:        "column of another table dropped",
+			up:          `ALTER TABLE movies ADD COLUMN rating integer;`,
+			down:        `ALTER TABLE reviews DROP COLUMN rating;`,
+			want:        true,
+			wantExplain: "Not dropped in the rollback: column movies.rating",
+		},
+		{
+			name: "unnamed index dropped with its default name",
+			up:   `CREATE INDEX ON movies (rating);`,
+			down: `DROP INDEX movies_rating_idx;`,
+			want: false,
+		},
+		{
+			name:        "index not dropped",
+			up:          `CREATE INDEX CONCURRENTLY movies_rating_idx ON movies (rating);`,
+			down:        `ALTER TABLE movies DROP COLUMN rating;`,
+			want:        true,
+			wantExplain: "Not dropped in the rollback: index movies_rating_idx",
+		},
+		{
+			name: "named constraint dropped",
+			up:   `ALTER TABLE movies ADD CONSTRAINT movies_rating_check CHECK (rating > 0);`,
+			down: `ALTER TABLE movies DROP CONSTRAINT movies_rating_check;`,
+			want: false,
+		},
+		{
+			name: "unnamed constraint on an unknown table dropped",
+			up:   `ALTER TABLE movies ADD UNIQUE (title);`,
+			down: `ALTER TABLE movies DROP CONSTRAINT movies_title_key;`,
+			want: false,
+		},
+		{
+			name:        "unnamed constraint not dropped",
+			up:          `ALTER TABLE movies ADD UNIQUE (title);`,
+			down:        `DROP INDEX movies_title_idx;`,
+			want:        true,
+			wantExplain: "Not dropped in the rollback: unnamed constraint on movies",
+		},
+		{
+			name: "types, sequences and schemas dropped",
+			up: `CREATE TYPE mood AS ENUM ('sad', 'happy');
+CREATE DOMAIN rating AS integer CHECK (VALUE > 0);
+CREATE SEQUENCE ticket_number;
+CREATE SCHEMA archive;
+CREATE MATERIALIZED VIEW top_movies AS SELECT * FROM movies;`,
+			down: `DROP MATERIALIZED VIEW top_movies;
+DROP SCHEMA archive;
+DROP SEQUENCE ticket_number;
+DROP DOMAIN rating;
+DROP TYPE mood;`,
+			want: false,
+		},
+		{
+			name:        "view not dropped",
+			up:          `CREATE VIEW recent_movies AS SELECT * FROM movies;`,
+			down:        `DROP MATERIALIZED VIEW recent_movies;`,
+			want:        true,
+			wantExplain: "Not dropped in the rollback: view recent_movies",
+		},
+		{
+			name: "empty rollback",
+			up:   `CREATE TABLE reviews (id bigint PRIMARY KEY);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			up := parseSection(t, tt.up)
+			ctx := MigrationContext{AllStatements: up, Direction: migrate.Up, Annotated: true, OppositeStatements: parseSection(t, tt.down)}
+			var (
+				rule         UpChangesNotReverted
+				got          bool
+				explanations []string
+			)
+			for _, node := range up {
+				if rule.Process(node, ctx) {
+					got = true
+					explanations = append(explanations, rule.Explain(node, ctx))
+				}
+			}
+			assert.Equal(t, tt.want, got)
+			if tt.want {
+				assert.Equal(t, tt.wantExplain, strings.Join(explanations, "; "))
+			}
+		})
+	}
+}
+
+func TestUpChangesNotReverted_Process_DefaultConstraintName(t *testing.T) {
+	t.Parallel()
+
+	up := parseSection(t, `ALTER TABLE movies ADD UNIQUE (title);`)
+	ctx := MigrationContext{
+		Catalog:            newCatalog(parseSection(t, `CREATE TABLE movies (id bigint, title text);`)),
+		AllStatements:      up,
+		Direction:          migrate.Up,
+		OppositeStatements: parseSection(t, `ALTER TABLE movies DROP CONSTRAINT movies_title_unique;`),
+	}
+	assert.True(t, UpChangesNotReverted{}.Process(up[0], ctx))
+	assert.Equal(t, "Not dropped in the rollback: constraint movies.movies_title_key", UpChangesNotReverted{}.Explain(up[0], ctx))
+}
+
+func TestUpChangesNotReverted_Process_DefaultIndexName(t *testing.T) {
+	t.Parallel()
+
+	up := parseSection(t, `CREATE INDEX ON movies (rating);`)
+	ctx := MigrationContext{
+		Catalog: newCatalog(parseSection(t, `CREATE TABLE movies (id bigint, rating integer);
+CREATE INDEX ON movies (rating);`)),
+		AllStatements:      up,
+		Direction:          migrate.Up,
+		OppositeStatements: parseSection(t, `DROP INDEX movies_rating_idx;`),
+	}
+	assert.True(t, UpChangesNotReverted{}.Process(up[0], ctx))
+	assert.Equal(t, "Not dropped in the rollback: index movies_rating_idx1", UpChangesNotReverted{}.Explain(up[0], ctx))
+
+	ctx.OppositeStatements = parseSection(t, `DROP INDEX movies_rating_idx1;`)
+	assert.False(t, UpChangesNotReverted{}.Process(up[0], ctx))
+}
+
+func TestDownDropsUnrelatedObject_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		up          string
+		down        string
+		want        bool
+		wantExplain string
+	}{
+		{
+			name: "table created",
+			up:   `CREATE TABLE reviews (id bigint PRIMARY KEY);`,
+			down: `DROP TABLE reviews;`,
+			want: false,
+		},
+		{
+			name: "column of a created table",
+			up:   `CREATE TABLE reviews (id bigint PRIMARY KEY, rating integer);`,
+			down: `ALTER TABLE reviews DROP COLUMN rating;`,
+			want: false,
+		},
+		{
+			name:        "table not created",
+			up:          `ALTER TABLE movies ADD COLUMN rating integer;`,
+			down:        `DROP TABLE movies;`,
+			want:        true,
+			wantExplain: "Not created in the forward migration: table movies",
+		},
+		{
+			name:        "column not created",
+			up:          `ALTER TABLE movies ADD COLUMN rating integer;`,
+			down:        `ALTER TABLE movies DROP COLUMN rating, DROP COLUMN budget;`,
+			want:        true,
+			wantExplain: "Not created in the forward migration: column movies.budget",
+		},
+		{
+			name: "unnamed index created",
+			up:   `CREATE INDEX ON movies (rating);`,
+			down: `DROP INDEX CONCURRENTLY movies_rating_idx;`,
+			want: false,
+		},
+		{
+			name:        "index not created",
+			up:          `CREATE INDEX movies_rating_idx ON movies (rating);`,
+			down:        `DROP INDEX movies_title_idx;`,
+			want:        true,
+			wantExplain: "Not created in the forward migration: index movies_title_idx",
+		},
+		{
+			name: "constraint of an unnamed constraint",
+			up:   `ALTER TABLE movies ADD CHECK (rating > 0);`,
+			down: `ALTER TABLE movies DROP CONSTRAINT movies_rating_check;`,
+			want: false,
+		},
+		{
+			name:        "type not created",
+			up:          `ALTER TYPE mood ADD VALUE 'neutral';`,
+			down:        `DROP TYPE mood;`,
+			want:        true,
+			wantExplain: "Not created in the forward migration: type mood",
+		},
+		{
+			name: "statements other than drops",
+			up:   `ALTER TABLE movies RENAME COLUMN name TO title;`,
+			down: `ALTER TABLE movies RENAME COLUMN title TO name;`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			down := parseSection(t, tt.down)
+			ctx := MigrationContext{AllStatements: down, Direction: migrate.Down, Annotated: true, OppositeStatements: parseSection(t, tt.up)}
+			assert.Equal(t, tt.want, DownDropsUnrelatedObject{}.Process(down[0], ctx))
+			if tt.want {
+				assert.Equal(t, tt.wantExplain, DownDropsUnrelatedObject{}.Explain(down[0], ctx))
+			}
+		})
+	}
+}
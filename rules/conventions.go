@@ -0,0 +1,241 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// PreferTimestamptz - timestamp columns store the local time without the time zone.
+type PreferTimestamptz struct{}
+
+func (r PreferTimestamptz) Alias() string {
+	return ConventionsRule("prefer-timestamptz")
+}
+
+// https://wiki.postgresql.org/wiki/Don't_Do_This#Don.27t_use_timestamp_.28without_time_zone.29
+func (r PreferTimestamptz) Documentation() string {
+	return "Prefer timestamptz over timestamp. timestamp (without time zone) columns store a local time without its time zone, " +
+		"so the stored values are interpreted differently depending on the session time zone and date arithmetic ignores daylight saving time changes. " +
+		"timestamptz stores an absolute point in time and uses the same amount of storage."
+}
+
+func (r PreferTimestamptz) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return len(matchingColumns(node, r.matches)) > 0
+}
+
+func (r PreferTimestamptz) Explain(node *pg_query.Node, _ MigrationContext) string {
+	return explainColumns(matchingColumns(node, r.matches))
+}
+
+func (r PreferTimestamptz) matches(c newColumn) bool {
+	return c.columnType.name == "timestamp"
+}
+
+// PreferText - varchar(n) columns enforce an arbitrary length limit that is expensive to change.
+type PreferText struct{}
+
+func (r PreferText) Alias() string {
+	return ConventionsRule("prefer-text-over-varchar")
+}
+
+// https://wiki.postgresql.org/wiki/Don't_Do_This#Don.27t_use_varchar.28n.29_by_default
+func (r PreferText) Documentation() string {
+	return "Prefer text over varchar(n). Both types have the same storage and performance characteristics, " +
+		"but decreasing the length limit of a varchar(n) column rewrites the table, and the limit is usually arbitrary. " +
+		"Use a CHECK constraint on the length when a limit is actually required, which can be replaced without a table rewrite."
+}
+
+func (r PreferText) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return len(matchingColumns(node, r.matches)) > 0
+}
+
+func (r PreferText) Explain(node *pg_query.Node, _ MigrationContext) string {
+	return explainColumns(matchingColumns(node, r.matches))
+}
+
+func (r PreferText) matches(c newColumn) bool {
+	return c.columnType.name == "varchar" && !c.columnType.unconstrained()
+}
+
+// PreferJSONB - json columns store an exact copy of the input text.
+type PreferJSONB struct{}
+
+func (r PreferJSONB) Alias() string {
+	return ConventionsRule("prefer-jsonb")
+}
+
+// https://www.postgresql.org/docs/current/datatype-json.html
+func (r PreferJSONB) Documentation() string {
+	return "Prefer jsonb over json. json columns store an exact copy of the input text that is reparsed on each access, " +
+		"and do not support equality comparison or indexing. " +
+		"Use json only when the key order, duplicate keys or whitespace of the input must be preserved."
+}
+
+func (r PreferJSONB) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return len(matchingColumns(node, r.matches)) > 0
+}
+
+func (r PreferJSONB) Explain(node *pg_query.Node, _ MigrationContext) string {
+	return explainColumns(matchingColumns(node, r.matches))
+}
+
+func (r PreferJSONB) matches(c newColumn) bool {
+	return c.columnType.name == "json"
+}
+
+// PreferBigintPrimaryKey - integer primary keys can run out of values.
+type PreferBigintPrimaryKey struct{}
+
+func (r PreferBigintPrimaryKey) Alias() string {
+	return ConventionsRule("prefer-bigint-identity-primary-key")
+}
+
+// https://wiki.postgresql.org/wiki/Don't_Do_This#Don.27t_use_serial
+func (r PreferBigintPrimaryKey) Documentation() string {
+	return "Prefer bigint identity columns (GENERATED ALWAYS AS IDENTITY) for primary keys. integer and smallint keys can run out of values, " +
+		"and changing the type of a primary key column rewrites the table and all the referencing foreign keys. " +
+		"serial types are backed by a separate sequence with its own ownership and permissions, while identity columns are part of the table definition."
+}
+
+func (r PreferBigintPrimaryKey) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return len(matchingColumns(node, r.matches)) > 0
+}
+
+func (r PreferBigintPrimaryKey) Explain(node *pg_query.Node, _ MigrationContext) string {
+	return explainColumns(matchingColumns(node, r.matches))
+}
+
+func (r PreferBigintPrimaryKey) matches(c newColumn) bool {
+	if !c.primaryKey {
+		return false
+	}
+	switch c.columnType.name {
+	// smallint and integer are parsed as int2 and int4
+	case "int2", "int4":
+		return true
+	}
+	return isSerialType(c.columnType)
+}
+
+// AvoidMoney - money values depend on the lc_monetary setting.
+type AvoidMoney struct{}
+
+func (r AvoidMoney) Alias() string {
+	return ConventionsRule("avoid-money")
+}
+
+// https://wiki.postgresql.org/wiki/Don't_Do_This#Don.27t_use_money
+func (r AvoidMoney) Documentation() string {
+	return "Avoid the money type. Its input and output formats depend on the lc_monetary setting, it stores a single currency " +
+		"with a fixed number of fractional digits and rounds values in division. Use numeric, along with a currency column when needed."
+}
+
+func (r AvoidMoney) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return len(matchingColumns(node, r.matches)) > 0
+}
+
+func (r AvoidMoney) Explain(node *pg_query.Node, _ MigrationContext) string {
+	return explainColumns(matchingColumns(node, r.matches))
+}
+
+func (r AvoidMoney) matches(c newColumn) bool {
+	return c.columnType.name == "money"
+}
+
+// AvoidChar - char(n) columns pad values with spaces.
+type AvoidChar struct{}
+
+func (r AvoidChar) Alias() string {
+	return ConventionsRule("avoid-char")
+}
+
+// https://wiki.postgresql.org/wiki/Don't_Do_This#Don.27t_use_char.28n.29
+func (r AvoidChar) Documentation() string {
+	return "Avoid char(n). Values are padded with spaces up to the column length, trailing spaces are ignored in comparisons " +
+		"and the type has no storage or performance advantage over text or varchar."
+}
+
+func (r AvoidChar) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return len(matchingColumns(node, r.matches)) > 0
+}
+
+func (r AvoidChar) Explain(node *pg_query.Node, _ MigrationContext) string {
+	return explainColumns(matchingColumns(node, r.matches))
+}
+
+func (r AvoidChar) matches(c newColumn) bool {
+	// char and character are parsed as bpchar
+	return c.columnType.name == "bpchar"
+}
+
+// newColumn is a column defined in a CREATE TABLE or ALTER TABLE ... ADD COLUMN statement.
+type newColumn struct {
+	table      string
+	name       string
+	columnType columnType
+	primaryKey bool
+}
+
+// newColumns returns the columns defined in a CREATE TABLE or ALTER TABLE ... ADD COLUMN statement.
+func newColumns(node *pg_query.Node) []newColumn {
+	var columns []newColumn
+	add := func(table string, columnDef *pg_query.ColumnDef, primaryKeys []string) {
+		c := newColumn{
+			table:      table,
+			name:       columnDef.GetColname(),
+			columnType: newColumnType(columnDef.GetTypeName()),
+		}
+		for _, constraint := range columnDef.GetConstraints() {
+			if constraint.GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_PRIMARY {
+				c.primaryKey = true
+			}
+		}
+		for _, key := range primaryKeys {
+			if key == c.name {
+				c.primaryKey = true
+			}
+		}
+		columns = append(columns, c)
+	}
+	if createStmt := node.GetCreateStmt(); createStmt != nil {
+		var primaryKeys []string
+		for _, elt := range createStmt.GetTableElts() {
+			if elt.GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_PRIMARY {
+				primaryKeys = append(primaryKeys, stringValues(elt.GetConstraint().GetKeys())...)
+			}
+		}
+		for _, elt := range createStmt.GetTableElts() {
+			if columnDef := elt.GetColumnDef(); columnDef != nil {
+				add(createStmt.GetRelation().GetRelname(), columnDef, primaryKeys)
+			}
+		}
+	}
+	if alterTableStmt := node.GetAlterTableStmt(); alterTableStmt != nil {
+		for _, cmd := range alterTableStmt.GetCmds() {
+			if cmd.GetAlterTableCmd().GetSubtype() == pg_query.AlterTableType_AT_AddColumn {
+				add(alterTableStmt.GetRelation().GetRelname(), cmd.GetAlterTableCmd().GetDef().GetColumnDef(), nil)
+			}
+		}
+	}
+	return columns
+}
+
+func matchingColumns(node *pg_query.Node, matches func(newColumn) bool) []newColumn {
+	var columns []newColumn
+	for _, c := range newColumns(node) {
+		if matches(c) {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+func explainColumns(columns []newColumn) string {
+	var descriptions []string
+	for _, c := range columns {
+		descriptions = append(descriptions, fmt.Sprintf("%s.%s (%s)", c.table, c.name, c.columnType))
+	}
+	return "Columns: " + strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,139 @@
+package rules
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConventions_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule Rule
+		sql  string
+		want bool
+	}{
+		{
+			name: "timestamp column",
+			rule: PreferTimestamptz{},
+			sql:  `CREATE TABLE movies (id bigint PRIMARY KEY, released_at timestamp);`,
+			want: true,
+		},
+		{
+			name: "timestamptz column",
+			rule: PreferTimestamptz{},
+			sql:  `ALTER TABLE movies ADD COLUMN released_at timestamp with time zone;`,
+			want: false,
+		},
+		{
+			name: "added timestamp column",
+			rule: PreferTimestamptz{},
+			sql:  `ALTER TABLE movies ADD COLUMN released_at timestamp without time zone;`,
+			want: true,
+		},
+		{
+			name: "varchar with length limit",
+			rule: PreferText{},
+			sql:  `CREATE TABLE movies (id bigint PRIMARY KEY, title character varying(255));`,
+			want: true,
+		},
+		{
+			name: "varchar without length limit",
+			rule: PreferText{},
+			sql:  `CREATE TABLE movies (id bigint PRIMARY KEY, title varchar);`,
+			want: false,
+		},
+		{
+			name: "json column",
+			rule: PreferJSONB{},
+			sql:  `ALTER TABLE movies ADD COLUMN metadata json;`,
+			want: true,
+		},
+		{
+			name: "jsonb column",
+			rule: PreferJSONB{},
+			sql:  `ALTER TABLE movies ADD COLUMN metadata jsonb;`,
+			want: false,
+		},
+		{
+			name: "serial primary key",
+			rule: PreferBigintPrimaryKey{},
+			sql:  `CREATE TABLE movies (id serial PRIMARY KEY);`,
+			want: true,
+		},
+		{
+			name: "integer primary key defined as table constraint",
+			rule: PreferBigintPrimaryKey{},
+			sql:  `CREATE TABLE movies (id integer, title text, PRIMARY KEY (id));`,
+			want: true,
+		},
+		{
+			name: "bigint identity primary key",
+			rule: PreferBigintPrimaryKey{},
+			sql:  `CREATE TABLE movies (id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY);`,
+			want: false,
+		},
+		{
+			name: "integer column that is not a primary key",
+			rule: PreferBigintPrimaryKey{},
+			sql:  `CREATE TABLE movies (id bigint PRIMARY KEY, year integer);`,
+			want: false,
+		},
+		{
+			name: "money column",
+			rule: AvoidMoney{},
+			sql:  `ALTER TABLE movies ADD COLUMN budget money;`,
+			want: true,
+		},
+		{
+			name: "numeric column",
+			rule: AvoidMoney{},
+			sql:  `ALTER TABLE movies ADD COLUMN budget numeric(12,2);`,
+			want: false,
+		},
+		{
+			name: "char column",
+			rule: AvoidChar{},
+			sql:  `CREATE TABLE movies (id bigint PRIMARY KEY, country_code char(2));`,
+			want: true,
+		},
+		{
+			name: "character column without length",
+			rule: AvoidChar{},
+			sql:  `ALTER TABLE movies ADD COLUMN rating character;`,
+			want: true,
+		},
+		{
+			name: "column type change is not reported",
+			rule: AvoidChar{},
+			sql:  `ALTER TABLE movies ALTER COLUMN country_code TYPE char(2);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.rule.Process(parseStatement(t, tt.sql), MigrationContext{}))
+		})
+	}
+}
+
+func TestConventions_Explain(t *testing.T) {
+	t.Parallel()
+
+	node := parseStatement(t, `CREATE TABLE movies (id bigint PRIMARY KEY, released_at timestamp, updated_at timestamp(3));`)
+	assert.Equal(t, "Columns: movies.released_at (timestamp), movies.updated_at (timestamp(3))",
+		PreferTimestamptz{}.Explain(node, MigrationContext{}))
+}
+
+func TestConventions_OptIn(t *testing.T) {
+	t.Parallel()
+
+	for alias := range All() {
+		if CategoryFromAlias(alias) == string(CategoryConventions) {
+			assert.True(t, IsOptIn(alias), alias)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/catalog"
+)
+
+// UnfilteredUpdateOrDelete - Updating or deleting all table rows in a single statement.
+type UnfilteredUpdateOrDelete struct{}
+
+func (r UnfilteredUpdateOrDelete) Alias() string {
+	return DataSafetyRule("update-or-delete-without-where")
+}
+
+func (r UnfilteredUpdateOrDelete) Documentation() string {
+	return "UPDATE or DELETE statements without a WHERE clause affect all table rows in a single transaction, " +
+		"holding row locks until the transaction ends and producing a large volume of WAL. Backfills should be performed in batches."
+}
+
+func (r UnfilteredUpdateOrDelete) Process(node *pg_query.Node, _ MigrationContext) bool {
+	if updateStmt := node.GetUpdateStmt(); updateStmt != nil {
+		return updateStmt.GetWhereClause() == nil
+	}
+	if deleteStmt := node.GetDeleteStmt(); deleteStmt != nil {
+		return deleteStmt.GetWhereClause() == nil
+	}
+	return false
+}
+
+// Truncate - Truncating a table removes all rows.
+type Truncate struct{}
+
+func (r Truncate) Alias() string {
+	return DataSafetyRule("avoid-truncate")
+}
+
+func (r Truncate) Documentation() string {
+	return "TRUNCATE removes all table rows and acquires an ACCESS EXCLUSIVE lock on the table."
+}
+
+func (r Truncate) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return node.GetTruncateStmt() != nil
+}
+
+// DropCascade - Dropping objects with CASCADE also drops the objects that depend on them.
+type DropCascade struct{}
+
+func (r DropCascade) Alias() string {
+	return DataSafetyRule("avoid-drop-cascade")
+}
+
+// https://www.postgresql.org/docs/current/ddl-depend.html
+func (r DropCascade) Documentation() string {
+	return "DROP ... CASCADE also drops all the objects that depend on the dropped objects, e.g. views, foreign key constraints and columns of a dropped type. " +
+		"Drop the dependent objects explicitly instead, so that the migration fails if there are unexpected dependencies."
+}
+
+func (r DropCascade) Process(node *pg_query.Node, _ MigrationContext) bool {
+	if dropStmt := node.GetDropStmt(); dropStmt != nil {
+		return dropStmt.GetBehavior() == pg_query.DropBehavior_DROP_CASCADE
+	}
+	for _, cmd := range node.GetAlterTableStmt().GetCmds() {
+		if cmd.GetAlterTableCmd().GetBehavior() == pg_query.DropBehavior_DROP_CASCADE {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain lists the dependent objects that are known to exist from the migration history and the preceding statements.
+// The dependents of columns and constraints dropped with ALTER TABLE are not resolved, as the catalog
+// does not record which columns views refer to.
+func (r DropCascade) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	var relations, types []string
+	if dropStmt := node.GetDropStmt(); dropStmt != nil {
+		for _, object := range dropStmt.GetObjects() {
+			switch dropStmt.GetRemoveType() {
+			case pg_query.ObjectType_OBJECT_TABLE, pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+				if names := stringValues(object.GetList().GetItems()); len(names) > 0 {
+					relations = append(relations, names[len(names)-1])
+				}
+			case pg_query.ObjectType_OBJECT_TYPE, pg_query.ObjectType_OBJECT_DOMAIN:
+				if names := stringValues(object.GetTypeName().GetNames()); len(names) > 0 {
+					types = append(types, names[len(names)-1])
+				}
+			}
+		}
+	}
+	if len(relations) == 0 && len(types) == 0 {
+		return ""
+	}
+	dependents := dependentObjects(ctx.CatalogBefore(node), relations, types)
+	if len(dependents) == 0 {
+		return ""
+	}
+	return "Known dependent objects: " + strings.Join(dependents, ", ")
+}
+
+// dependentObjects returns the foreign keys and views that refer to the given relations and the columns of the given types.
+func dependentObjects(cat *catalog.Catalog, relations, types []string) []string {
+	refersTo := func(names []string, name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	var dependents []string
+	for _, table := range cat.Tables() {
+		for _, column := range table.Columns {
+			if names := stringValues(column.Type.GetNames()); len(names) > 0 && refersTo(types, names[len(names)-1]) {
+				dependents = append(dependents, fmt.Sprintf("column %s.%s", table.Name, column.Name))
+			}
+		}
+		for _, constraint := range table.Constraints {
+			// Self-referencing foreign keys are dropped along with the table
+			if constraint.Type == catalog.ForeignKey && refersTo(relations, constraint.RefTable) && constraint.RefTable != table.Name {
+				dependents = append(dependents, fmt.Sprintf("constraint %s on %s", constraint.Name, table.Name))
+			}
+		}
+	}
+	for _, view := range cat.Views() {
+		if refersTo(relations, view.Name) {
+			continue
+		}
+		var dependent bool
+		walk(view.Query, func(n *pg_query.Node) bool {
+			dependent = dependent || refersTo(relations, n.GetRangeVar().GetRelname())
+			return !dependent
+		})
+		if !dependent {
+			continue
+		}
+		if view.Materialized {
+			dependents = append(dependents, "materialized view "+view.Name)
+		} else {
+			dependents = append(dependents, "view "+view.Name)
+		}
+	}
+	return dependents
+}
@@ -0,0 +1,170 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestUnfilteredUpdateOrDelete_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "update without WHERE clause",
+			sql:  `UPDATE films SET updated_at = CURRENT_TIMESTAMP;`,
+			want: true,
+		},
+		{
+			name: "update with WHERE clause",
+			sql:  `UPDATE films SET updated_at = CURRENT_TIMESTAMP WHERE updated_at IS NULL;`,
+			want: false,
+		},
+		{
+			name: "delete without WHERE clause",
+			sql:  `DELETE FROM films USING directors;`,
+			want: true,
+		},
+		{
+			name: "delete with WHERE clause",
+			sql:  `DELETE FROM films WHERE id = 1;`,
+			want: false,
+		},
+		{
+			name: "insert statement",
+			sql:  `INSERT INTO films (title) VALUES ('Vertigo');`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := UnfilteredUpdateOrDelete{}
+			assert.Equal(t, tt.want, r.Process(parseStatement(t, tt.sql), MigrationContext{}))
+		})
+	}
+}
+
+func TestTruncate_Process(t *testing.T) {
+	t.Parallel()
+
+	r := Truncate{}
+	assert.True(t, r.Process(parseStatement(t, `TRUNCATE films, directors;`), MigrationContext{}))
+	assert.False(t, r.Process(parseStatement(t, `DELETE FROM films WHERE id = 1;`), MigrationContext{}))
+}
+
+func TestDropCascade_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "drop table with cascade",
+			sql:  `DROP TABLE movies CASCADE;`,
+			want: true,
+		},
+		{
+			name: "drop table without cascade",
+			sql:  `DROP TABLE movies;`,
+			want: false,
+		},
+		{
+			name: "drop type with cascade",
+			sql:  `DROP TYPE IF EXISTS mood CASCADE;`,
+			want: true,
+		},
+		{
+			name: "drop column with cascade",
+			sql:  `ALTER TABLE movies DROP COLUMN rating CASCADE;`,
+			want: true,
+		},
+		{
+			name: "drop constraint with restrict",
+			sql:  `ALTER TABLE movies DROP CONSTRAINT movies_rating_check RESTRICT;`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := DropCascade{}
+			assert.Equal(t, tt.want, r.Process(parseStatement(t, tt.sql), MigrationContext{}))
+		})
+	}
+}
+
+func TestDropCascade_Explain(t *testing.T) {
+	t.Parallel()
+
+	parse := func(sql string) []*pg_query.Node {
+		var nodes []*pg_query.Node
+		for _, statement := range strings.Split(sql, "\n") {
+			nodes = append(nodes, parseStatement(t, statement))
+		}
+		return nodes
+	}
+	tests := []struct {
+		name    string
+		history string
+		sql     string
+		want    string
+	}{
+		{
+			name: "views and foreign keys referencing the table",
+			history: `CREATE TABLE movies (id bigint PRIMARY KEY, parent_id bigint REFERENCES movies (id));
+CREATE TABLE ratings (id bigint PRIMARY KEY, movie_id bigint REFERENCES movies (id));
+CREATE TABLE awards (id bigint PRIMARY KEY, movie_id bigint);
+ALTER TABLE awards ADD CONSTRAINT awards_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES movies (id);
+CREATE VIEW recent_movies AS SELECT * FROM movies WHERE id > 100;
+CREATE MATERIALIZED VIEW movie_ratings AS SELECT m.id, avg(r.rating) FROM movies m JOIN ratings r ON r.movie_id = m.id GROUP BY m.id;
+CREATE VIEW old_movies AS SELECT * FROM movies WHERE id < 100;`,
+			sql: `DROP VIEW old_movies;
+DROP TABLE movies CASCADE;`,
+			want: "Known dependent objects: constraint awards_movie_id_fkey on awards, constraint ratings_movie_id_fkey on ratings, materialized view movie_ratings, view recent_movies",
+		},
+		{
+			name:    "columns of the dropped type",
+			history: `CREATE TYPE mood AS ENUM ('sad', 'happy');`,
+			sql: `CREATE TABLE people (id bigint PRIMARY KEY, current_mood mood);
+DROP TYPE mood CASCADE;`,
+			want: "Known dependent objects: column people.current_mood",
+		},
+		{
+			name: "dependents of other columns of the table",
+			history: `CREATE TABLE movies (id bigint PRIMARY KEY, rating integer);
+CREATE TABLE ratings (id bigint PRIMARY KEY, movie_id bigint REFERENCES movies (id));
+CREATE VIEW recent_movies AS SELECT id FROM movies;`,
+			sql:  `ALTER TABLE movies DROP COLUMN rating CASCADE;`,
+			want: "",
+		},
+		{
+			name:    "no known dependent objects",
+			history: `CREATE TABLE movies (id bigint PRIMARY KEY);`,
+			sql:     `DROP TABLE movies CASCADE;`,
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			allNodes := parse(tt.sql)
+			r := DropCascade{}
+			assert.Equal(t, tt.want, r.Explain(allNodes[len(allNodes)-1], MigrationContext{
+				AllStatements: allNodes,
+				Catalog:       newCatalog(parse(tt.history)),
+			}))
+		})
+	}
+}
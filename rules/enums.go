@@ -0,0 +1,211 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/catalog"
+)
+
+// EnumAddValueInTransaction - Before PostgreSQL 12, adding an enum value cannot be executed inside a transaction.
+type EnumAddValueInTransaction struct{}
+
+func (r EnumAddValueInTransaction) Alias() string {
+	return TransactionRule("enum-add-value-cannot-be-executed-in-transaction")
+}
+
+// https://www.postgresql.org/docs/11/sql-altertype.html#id-1.9.3.42.7
+func (r EnumAddValueInTransaction) Documentation() string {
+	return "Before PostgreSQL 12, ALTER TYPE ... ADD VALUE cannot be executed inside a transaction."
+}
+
+func (r EnumAddValueInTransaction) PostgresVersions() VersionRange {
+	return VersionRange{Max: 12}
+}
+
+func (r EnumAddValueInTransaction) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if !isEnumAddValue(node) {
+		return false
+	}
+	_, inTransaction := transactionStatements(node, ctx)
+	return inTransaction
+}
+
+// EnumNewValueUsedInTransaction - An enum value cannot be used in the same transaction that added it.
+type EnumNewValueUsedInTransaction struct{}
+
+func (r EnumNewValueUsedInTransaction) Alias() string {
+	return TransactionRule("enum-new-value-used-in-same-transaction")
+}
+
+// https://www.postgresql.org/docs/current/sql-altertype.html#SQL-ALTERTYPE-NOTES
+func (r EnumNewValueUsedInTransaction) Documentation() string {
+	return "An enum value added with ALTER TYPE ... ADD VALUE cannot be used until the transaction that added it has been committed."
+}
+
+// Before PostgreSQL 12, ADD VALUE itself fails inside a transaction.
+func (r EnumNewValueUsedInTransaction) PostgresVersions() VersionRange {
+	return VersionRange{Min: 12}
+}
+
+func (r EnumNewValueUsedInTransaction) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	return len(r.addedValuesInUse(node, ctx)) > 0
+}
+
+func (r EnumNewValueUsedInTransaction) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	var values []string
+	for _, v := range r.addedValuesInUse(node, ctx) {
+		values = append(values, fmt.Sprintf("'%s'", v))
+	}
+	return fmt.Sprintf("Enum values added in the same transaction: %s", strings.Join(values, ", "))
+}
+
+// addedValuesInUse returns the enum values used in the statement,
+// that have been added by a preceding statement in the same transaction.
+func (r EnumNewValueUsedInTransaction) addedValuesInUse(node *pg_query.Node, ctx MigrationContext) []string {
+	if isEnumAddValue(node) {
+		return nil
+	}
+	statements, inTransaction := transactionStatements(node, ctx)
+	if !inTransaction {
+		return nil
+	}
+	added := make(map[typedLiteral]bool)
+	for _, n := range statements {
+		if isEnumAddValue(n) {
+			alterEnumStmt := n.GetAlterEnumStmt()
+			added[typedLiteral{typ: lastName(alterEnumStmt.GetTypeName()), value: alterEnumStmt.GetNewVal()}] = true
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	var used []string
+	for _, literal := range typedLiterals(node, ctx) {
+		if added[literal] {
+			used = append(used, literal.value)
+			delete(added, literal)
+		}
+	}
+	return used
+}
+
+// typedLiteral is a string literal used as a value of the given type.
+type typedLiteral struct {
+	typ   string
+	value string
+}
+
+// typedLiterals returns the string literals of the statement whose type is known:
+// the literals cast to a type, used as column defaults, and assigned to or compared with a column of the schema.
+// Other literals, e.g. compared with text columns of unknown tables, are not returned.
+func typedLiterals(node *pg_query.Node, ctx MigrationContext) []typedLiteral {
+	var (
+		literals []typedLiteral
+		tables   []string
+		cat      *catalog.Catalog
+	)
+	// columnType returns the type of the column in the tables of the statement, the schema is only built when needed
+	columnType := func(column string) string {
+		if cat == nil {
+			cat = ctx.CatalogBefore(node)
+		}
+		for _, name := range tables {
+			if table, ok := cat.Table(name); ok {
+				if c, ok := table.Column(column); ok && c.Type != nil {
+					return lastName(c.Type.GetNames())
+				}
+			}
+		}
+		return ""
+	}
+	add := func(typ string, value *pg_query.Node) {
+		if s, ok := stringLiteral(value); ok && typ != "" {
+			literals = append(literals, typedLiteral{typ: typ, value: s})
+		}
+	}
+
+	switch {
+	case node.GetUpdateStmt() != nil:
+		tables = append(tables, node.GetUpdateStmt().GetRelation().GetRelname())
+	case node.GetInsertStmt() != nil:
+		tables = append(tables, node.GetInsertStmt().GetRelation().GetRelname())
+	case node.GetDeleteStmt() != nil:
+		tables = append(tables, node.GetDeleteStmt().GetRelation().GetRelname())
+	case node.GetAlterTableStmt() != nil:
+		tables = append(tables, node.GetAlterTableStmt().GetRelation().GetRelname())
+	}
+	walk(node, func(n *pg_query.Node) bool {
+		if rangeVar := n.GetRangeVar(); rangeVar != nil {
+			tables = append(tables, rangeVar.GetRelname())
+		}
+		return true
+	})
+
+	switch {
+	case node.GetUpdateStmt() != nil:
+		for _, target := range node.GetUpdateStmt().GetTargetList() {
+			if _, ok := stringLiteral(target.GetResTarget().GetVal()); ok {
+				add(columnType(target.GetResTarget().GetName()), target.GetResTarget().GetVal())
+			}
+		}
+	case node.GetInsertStmt() != nil:
+		columns := node.GetInsertStmt().GetCols()
+		for _, values := range node.GetInsertStmt().GetSelectStmt().GetSelectStmt().GetValuesLists() {
+			for i, value := range values.GetList().GetItems() {
+				if _, ok := stringLiteral(value); ok && i < len(columns) {
+					add(columnType(columns[i].GetResTarget().GetName()), value)
+				}
+			}
+		}
+	}
+	walk(node, func(n *pg_query.Node) bool {
+		switch {
+		case n.GetTypeCast() != nil:
+			add(lastName(n.GetTypeCast().GetTypeName().GetNames()), n.GetTypeCast().GetArg())
+		case n.GetColumnDef() != nil:
+			for _, constraint := range n.GetColumnDef().GetConstraints() {
+				if constraint.GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_DEFAULT {
+					add(lastName(n.GetColumnDef().GetTypeName().GetNames()), constraint.GetConstraint().GetRawExpr())
+				}
+			}
+		case n.GetAlterTableCmd().GetSubtype() == pg_query.AlterTableType_AT_ColumnDefault:
+			if _, ok := stringLiteral(n.GetAlterTableCmd().GetDef()); ok {
+				add(columnType(n.GetAlterTableCmd().GetName()), n.GetAlterTableCmd().GetDef())
+			}
+		case n.GetAExpr() != nil:
+			aExpr := n.GetAExpr()
+			for _, sides := range [][2]*pg_query.Node{{aExpr.GetLexpr(), aExpr.GetRexpr()}, {aExpr.GetRexpr(), aExpr.GetLexpr()}} {
+				column, other := sides[0].GetColumnRef(), sides[1]
+				if column == nil {
+					continue
+				}
+				values := []*pg_query.Node{other}
+				if other.GetList() != nil {
+					values = other.GetList().GetItems()
+				}
+				for _, value := range values {
+					if _, ok := stringLiteral(value); ok {
+						add(columnType(lastName(column.GetFields())), value)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return literals
+}
+
+func stringLiteral(node *pg_query.Node) (string, bool) {
+	if s := node.GetAConst().GetSval(); s != nil {
+		return s.GetSval(), true
+	}
+	return "", false
+}
+
+func isEnumAddValue(node *pg_query.Node) bool {
+	alterEnumStmt := node.GetAlterEnumStmt()
+	// RENAME VALUE statements define the old value
+	return alterEnumStmt != nil && alterEnumStmt.GetOldVal() == ""
+}
@@ -0,0 +1,172 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestEnumAddValueInTransaction_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		sql           string
+		inTransaction bool
+		want          bool
+	}{
+		{
+			name:          "enum value added in transaction",
+			sql:           `ALTER TYPE mood ADD VALUE 'ecstatic';`,
+			inTransaction: true,
+			want:          true,
+		},
+		{
+			name:          "enum value added without transaction",
+			sql:           `ALTER TYPE mood ADD VALUE 'ecstatic';`,
+			inTransaction: false,
+			want:          false,
+		},
+		{
+			name: "enum value added in explicit transaction block",
+			sql: `BEGIN;
+ALTER TYPE mood ADD VALUE 'ecstatic';`,
+			inTransaction: false,
+			want:          true,
+		},
+		{
+			name:          "enum value renamed in transaction",
+			sql:           `ALTER TYPE mood RENAME VALUE 'sad' TO 'blue';`,
+			inTransaction: true,
+			want:          false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			r := EnumAddValueInTransaction{}
+			assert.Equal(t, tt.want, r.Process(allNodes[len(allNodes)-1], MigrationContext{
+				AllStatements: allNodes,
+				InTransaction: tt.inTransaction,
+			}))
+		})
+	}
+}
+
+func TestEnumNewValueUsedInTransaction_Process(t *testing.T) {
+	t.Parallel()
+
+	cat := newCatalog(parseSection(t, `CREATE TYPE mood AS ENUM ('sad', 'happy');
+CREATE TABLE people (id bigint PRIMARY KEY, name text, current_mood mood);
+CREATE TABLE notes (id bigint PRIMARY KEY, body text);`))
+	tests := []struct {
+		name          string
+		sql           string
+		inTransaction bool
+		want          bool
+		wantDetails   string
+	}{
+		{
+			name: "new value used in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+UPDATE people SET current_mood = 'ecstatic' WHERE current_mood = 'happy';`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "Enum values added in the same transaction: 'ecstatic'",
+		},
+		{
+			name: "new value used as column default in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+ALTER TABLE people ALTER COLUMN current_mood SET DEFAULT 'ecstatic';`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "Enum values added in the same transaction: 'ecstatic'",
+		},
+		{
+			name: "new value used after the transaction is committed",
+			sql: `BEGIN;
+ALTER TYPE mood ADD VALUE 'ecstatic';
+COMMIT;
+UPDATE people SET current_mood = 'ecstatic' WHERE current_mood = 'happy';`,
+			inTransaction: false,
+			want:          false,
+		},
+		{
+			name: "new value inserted in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+INSERT INTO people (name, current_mood) VALUES ('Alice', 'ecstatic');`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "Enum values added in the same transaction: 'ecstatic'",
+		},
+		{
+			name: "new value cast to the enum type in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+SELECT 'ecstatic'::mood;`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "Enum values added in the same transaction: 'ecstatic'",
+		},
+		{
+			name: "new value used as default of a new column in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+ALTER TABLE notes ADD COLUMN mood mood DEFAULT 'ecstatic';`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "Enum values added in the same transaction: 'ecstatic'",
+		},
+		{
+			name: "same string used for a text column in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+UPDATE notes SET body = 'ecstatic' WHERE id = 1;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "same string compared with a text column in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+DELETE FROM notes WHERE body IN ('ecstatic', 'sad');`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "same string cast to another type in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+SELECT 'ecstatic'::text;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "other values used in the same transaction",
+			sql: `ALTER TYPE mood ADD VALUE 'ecstatic';
+UPDATE people SET current_mood = 'sad' WHERE current_mood = 'happy';`,
+			inTransaction: true,
+			want:          false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			node := allNodes[len(allNodes)-1]
+			ctx := MigrationContext{AllStatements: allNodes, Catalog: cat, InTransaction: tt.inTransaction}
+			r := EnumNewValueUsedInTransaction{}
+			assert.Equal(t, tt.want, r.Process(node, ctx))
+			if tt.want {
+				assert.Equal(t, tt.wantDetails, r.Explain(node, ctx))
+			}
+		})
+	}
+}
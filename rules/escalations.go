@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/locks"
+)
+
+// DefaultMaxAccessExclusiveRelations is the default number of relations that a transaction can lock in ACCESS EXCLUSIVE mode.
+const DefaultMaxAccessExclusiveRelations = 2
+
+// TransactionLocks - Locks acquired in one transaction are held until it ends, widening the window for lock queues and deadlocks.
+type TransactionLocks struct {
+	NewTableExemption           `yaml:",inline"`
+	MaxAccessExclusiveRelations int `yaml:"max-access-exclusive-relations"`
+}
+
+func (r TransactionLocks) Alias() string {
+	return HighAvailabilityRule("limit-locks-per-transaction")
+}
+
+// https://www.postgresql.org/docs/current/explicit-locking.html#LOCKING-DEADLOCKS
+func (r TransactionLocks) Documentation() string {
+	return "Locks are held until the end of the transaction. Separate ALTER TABLE statements on the same table " +
+		"each scan or rewrite the table while the lock is held: combine the subcommands into a single ALTER TABLE statement. " +
+		"Transactions that lock many tables in ACCESS EXCLUSIVE mode, or that lock tables in a different order than other migrations, " +
+		"are prone to long lock queues and deadlocks: split them into separate migrations."
+}
+
+func (r TransactionLocks) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	if r.MaxAccessExclusiveRelations < 1 {
+		return nil, fmt.Errorf("max-access-exclusive-relations must be positive, got %d", r.MaxAccessExclusiveRelations)
+	}
+	return r, nil
+}
+
+func (r TransactionLocks) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	return len(r.problems(node, ctx)) > 0
+}
+
+func (r TransactionLocks) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	return strings.Join(r.problems(node, ctx), "; ")
+}
+
+func (r TransactionLocks) problems(node *pg_query.Node, ctx MigrationContext) []string {
+	transaction, transactionLocks, index := transactionOf(node, ctx)
+	if index < 0 {
+		return nil
+	}
+	exempt := func(relation string) bool {
+		return !r.CheckNewTables && ctx.CreatedInMigration(relation, node)
+	}
+	var problems []string
+	if alterTableStmt := node.GetAlterTableStmt(); alterTableStmt.GetObjtype() == pg_query.ObjectType_OBJECT_TABLE {
+		table := alterTableStmt.GetRelation().GetRelname()
+		for _, statement := range transaction.Statements[:index] {
+			if statement.Node.GetAlterTableStmt().GetRelation().GetRelname() == table && !exempt(table) {
+				problems = append(problems, fmt.Sprintf("%s is already altered by a preceding ALTER TABLE statement "+
+					"of the same transaction, combine the subcommands into a single ALTER TABLE statement", table))
+				break
+			}
+		}
+	}
+
+	var held []locks.HeldLock
+	for _, lock := range transactionLocks {
+		if !exempt(lock.Relation) {
+			held = append(held, lock)
+		}
+	}
+	var exclusiveBefore, exclusive []string
+	for _, lock := range held {
+		if lock.Mode != locks.AccessExclusive || lock.From > index {
+			continue
+		}
+		if lock.From < index {
+			exclusiveBefore = append(exclusiveBefore, lock.Relation)
+		}
+		exclusive = append(exclusive, lock.Relation)
+	}
+	// Reported once, by the statement that exceeds the limit
+	if len(exclusiveBefore) <= r.MaxAccessExclusiveRelations && len(exclusive) > r.MaxAccessExclusiveRelations {
+		problems = append(problems, fmt.Sprintf("the transaction locks %d relations in ACCESS EXCLUSIVE mode (%s), more than %d",
+			len(exclusive), strings.Join(exclusive, ", "), r.MaxAccessExclusiveRelations))
+	}
+
+	var lockedBefore []string
+	for _, lock := range held {
+		if lock.From < index && lock.Mode.BlocksWrites() {
+			lockedBefore = append(lockedBefore, lock.Relation)
+		}
+	}
+	for _, lock := range held {
+		if lock.From != index || !lock.Mode.BlocksWrites() {
+			continue
+		}
+		for _, relation := range lockedBefore {
+			if relation == lock.Relation {
+				continue
+			}
+			if path, ok := ctx.LockHistory.LockedBefore(lock.Relation, relation); ok {
+				problems = append(problems, fmt.Sprintf("%s is locked after %s, while migration %s locks them in the opposite order",
+					lock.Relation, relation, filepath.Base(path)))
+			}
+		}
+	}
+	return problems
+}
+
+// transactionOf returns the transaction that executes the statement, with the locks acquired by each of its statements,
+// the locks held during the transaction and the index of the statement in the transaction.
+// The index is negative if the statement is not found. The timeline is built once per migration section.
+func transactionOf(node *pg_query.Node, ctx MigrationContext) (locks.Transaction, []locks.HeldLock, int) {
+	cache := ctx.sections()
+	cache.timelineOnce.Do(func() {
+		var statements []locks.Statement
+		for _, n := range ctx.AllStatements {
+			statements = append(statements, locks.Statement{Node: n})
+		}
+		cache.transactions = locks.Timeline(statements, ctx.InTransaction, ctx.Catalog)
+		cache.timelinePositions = make(map[*pg_query.Node][2]int, len(statements))
+		for t, transaction := range cache.transactions {
+			cache.held = append(cache.held, transaction.Held())
+			for i, statement := range transaction.Statements {
+				cache.timelinePositions[statement.Node] = [2]int{t, i}
+			}
+		}
+	})
+	position, ok := cache.timelinePositions[node]
+	if !ok {
+		return locks.Transaction{}, nil, -1
+	}
+	return cache.transactions[position[0]], cache.held[position[0]], position[1]
+}
@@ -0,0 +1,132 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"pgsafemigrate/locks"
+	"strings"
+	"testing"
+)
+
+func TestTransactionLocks(t *testing.T) {
+	t.Parallel()
+
+	history := locks.NewHistory()
+	var previous []locks.Statement
+	for _, statement := range []string{
+		`ALTER TABLE directors ADD COLUMN born_at date;`,
+		`ALTER TABLE movies ADD COLUMN director_name text;`,
+	} {
+		previous = append(previous, locks.Statement{Node: parseStatement(t, statement)})
+	}
+	history.Record("/migrations/20230101000000-directors.sql", locks.Timeline(previous, true, nil))
+
+	tests := []struct {
+		name          string
+		sql           string
+		notransaction bool
+		rule          TransactionLocks
+		want          bool
+		wantExplain   string
+	}{
+		{
+			name: "single ALTER TABLE with multiple subcommands",
+			sql:  `ALTER TABLE movies ADD COLUMN rating integer, ADD COLUMN budget integer;`,
+			want: false,
+		},
+		{
+			name: "separate ALTER TABLE statements on the same table",
+			sql: `ALTER TABLE movies ADD COLUMN rating integer;
+ALTER TABLE movies ADD COLUMN budget integer;`,
+			want: true,
+			wantExplain: "movies is already altered by a preceding ALTER TABLE statement of the same transaction, " +
+				"combine the subcommands into a single ALTER TABLE statement",
+		},
+		{
+			name: "separate ALTER TABLE statements outside a transaction",
+			sql: `ALTER TABLE movies ADD COLUMN rating integer;
+ALTER TABLE movies ADD COLUMN budget integer;`,
+			notransaction: true,
+			want:          false,
+		},
+		{
+			name: "separate ALTER TABLE statements on a table created in the same migration",
+			sql: `CREATE TABLE reviews (id bigint PRIMARY KEY);
+ALTER TABLE reviews ADD COLUMN rating integer;
+ALTER TABLE reviews ADD COLUMN body text;`,
+			want: false,
+		},
+		{
+			name: "separate ALTER TABLE statements on a table created in the same migration with check-new-tables",
+			sql: `CREATE TABLE reviews (id bigint PRIMARY KEY);
+ALTER TABLE reviews ADD COLUMN rating integer;
+ALTER TABLE reviews ADD COLUMN body text;`,
+			rule: TransactionLocks{NewTableExemption: NewTableExemption{CheckNewTables: true}},
+			want: true,
+			wantExplain: "reviews is already altered by a preceding ALTER TABLE statement of the same transaction, " +
+				"combine the subcommands into a single ALTER TABLE statement",
+		},
+		{
+			name: "ACCESS EXCLUSIVE locks within the limit",
+			sql: `ALTER TABLE awards ADD COLUMN year integer;
+TRUNCATE reviews;`,
+			want: false,
+		},
+		{
+			name: "ACCESS EXCLUSIVE locks exceeding the limit",
+			sql: `ALTER TABLE awards ADD COLUMN year integer;
+TRUNCATE reviews;
+DROP TABLE ratings;`,
+			want:        true,
+			wantExplain: "the transaction locks 3 relations in ACCESS EXCLUSIVE mode (awards, reviews, ratings), more than 2",
+		},
+		{
+			name: "ACCESS EXCLUSIVE locks exceeding a custom limit",
+			sql: `ALTER TABLE awards ADD COLUMN year integer;
+TRUNCATE reviews;`,
+			rule:        TransactionLocks{MaxAccessExclusiveRelations: 1},
+			want:        true,
+			wantExplain: "the transaction locks 2 relations in ACCESS EXCLUSIVE mode (awards, reviews), more than 1",
+		},
+		{
+			name: "lock order of a preceding migration",
+			sql: `CREATE INDEX directors_name_idx ON directors (name);
+CREATE INDEX movies_rating_idx ON movies (rating);`,
+			want: false,
+		},
+		{
+			name: "lock order opposite to a preceding migration",
+			sql: `CREATE INDEX movies_rating_idx ON movies (rating);
+CREATE INDEX directors_name_idx ON directors (name);`,
+			want:        true,
+			wantExplain: "directors is locked after movies, while migration 20230101000000-directors.sql locks them in the opposite order",
+		},
+		{
+			name: "lock that does not block writes",
+			sql: `CREATE INDEX movies_rating_idx ON movies (rating);
+SELECT * FROM directors;`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := tt.rule
+			if rule.MaxAccessExclusiveRelations == 0 {
+				rule.MaxAccessExclusiveRelations = DefaultMaxAccessExclusiveRelations
+			}
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			node := allNodes[len(allNodes)-1]
+			ctx := MigrationContext{AllStatements: allNodes, InTransaction: !tt.notransaction, LockHistory: history}
+			assert.Equal(t, tt.want, rule.Process(node, ctx))
+			if tt.want {
+				assert.Equal(t, tt.wantExplain, rule.Explain(node, ctx))
+			}
+		})
+	}
+}
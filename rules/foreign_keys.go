@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// ForeignKeyRequiresIndex - Foreign key columns without an index cause sequential scans when the referenced rows change.
+type ForeignKeyRequiresIndex struct{}
+
+func (r ForeignKeyRequiresIndex) Alias() string {
+	return PerformanceRule("foreign-key-requires-index")
+}
+
+func (r ForeignKeyRequiresIndex) Documentation() string {
+	return "Foreign key columns should be the leading columns of an index. Otherwise, deleting or updating rows of the referenced table " +
+		"requires a sequential scan of the referencing table, while holding locks on both tables."
+}
+
+func (r ForeignKeyRequiresIndex) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	return len(r.unindexedForeignKeys(node, ctx)) > 0
+}
+
+func (r ForeignKeyRequiresIndex) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	var foreignKeys []string
+	for _, fk := range r.unindexedForeignKeys(node, ctx) {
+		foreignKeys = append(foreignKeys, fmt.Sprintf("%s (%s)", fk.table, strings.Join(fk.columns, ", ")))
+	}
+	return "No index found with leading columns: " + strings.Join(foreignKeys, "; ")
+}
+
+func (r ForeignKeyRequiresIndex) unindexedForeignKeys(node *pg_query.Node, ctx MigrationContext) []foreignKey {
+	foreignKeys := foreignKeysOf(node)
+	if len(foreignKeys) == 0 {
+		return nil
+	}
+	// Indexes can be created either before or after the foreign key, in the same migration
+	cat := ctx.CatalogAfter()
+	var unindexed []foreignKey
+	for _, fk := range foreignKeys {
+		var indexed bool
+		for _, index := range cat.Indexes(fk.table) {
+			if !index.Partial && hasLeadingColumns(index.Columns, fk.columns) {
+				indexed = true
+				break
+			}
+		}
+		if !indexed {
+			unindexed = append(unindexed, fk)
+		}
+	}
+	return unindexed
+}
+
+type foreignKey struct {
+	table   string
+	columns []string
+}
+
+// foreignKeysOf returns the foreign keys defined in a CREATE TABLE or ALTER TABLE statement.
+func foreignKeysOf(node *pg_query.Node) []foreignKey {
+	var foreignKeys []foreignKey
+	addColumn := func(table string, columnDef *pg_query.ColumnDef) {
+		for _, c := range columnDef.GetConstraints() {
+			if c.GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_FOREIGN {
+				foreignKeys = append(foreignKeys, foreignKey{table: table, columns: []string{columnDef.GetColname()}})
+			}
+		}
+	}
+	addConstraint := func(table string, constraint *pg_query.Constraint) {
+		if constraint.GetContype() == pg_query.ConstrType_CONSTR_FOREIGN {
+			foreignKeys = append(foreignKeys, foreignKey{table: table, columns: stringValues(constraint.GetFkAttrs())})
+		}
+	}
+	if createStmt := node.GetCreateStmt(); createStmt != nil {
+		table := createStmt.GetRelation().GetRelname()
+		for _, elt := range createStmt.GetTableElts() {
+			if columnDef := elt.GetColumnDef(); columnDef != nil {
+				addColumn(table, columnDef)
+			}
+			if constraint := elt.GetConstraint(); constraint != nil {
+				addConstraint(table, constraint)
+			}
+		}
+	}
+	if alterTableStmt := node.GetAlterTableStmt(); alterTableStmt != nil {
+		table := alterTableStmt.GetRelation().GetRelname()
+		for _, cmd := range alterTableStmt.GetCmds() {
+			alterTableCmd := cmd.GetAlterTableCmd()
+			switch alterTableCmd.GetSubtype() {
+			case pg_query.AlterTableType_AT_AddColumn:
+				addColumn(table, alterTableCmd.GetDef().GetColumnDef())
+			case pg_query.AlterTableType_AT_AddConstraint:
+				addConstraint(table, alterTableCmd.GetDef().GetConstraint())
+			}
+		}
+	}
+	return foreignKeys
+}
+
+// hasLeadingColumns reports whether the first index columns are the given columns, in any order.
+func hasLeadingColumns(indexColumns, columns []string) bool {
+	if len(columns) == 0 || len(indexColumns) < len(columns) {
+		return false
+	}
+	leading := make(map[string]bool, len(columns))
+	for _, c := range indexColumns[:len(columns)] {
+		leading[c] = true
+	}
+	for _, c := range columns {
+		if !leading[c] {
+			return false
+		}
+	}
+	return true
+}
+
+func stringValues(nodes []*pg_query.Node) []string {
+	var values []string
+	for _, n := range nodes {
+		values = append(values, n.GetString_().GetSval())
+	}
+	return values
+}
@@ -0,0 +1,119 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestForeignKeyRequiresIndex_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		history string
+		sql     string
+		// statement is the index of the processed statement in sql
+		statement int
+		want      bool
+	}{
+		{
+			name: "column-level foreign key without index",
+			sql:  `CREATE TABLE ratings (id bigint PRIMARY KEY, movie_id bigint REFERENCES movies (id));`,
+			want: true,
+		},
+		{
+			name: "table-level foreign key without index",
+			sql:  `CREATE TABLE ratings (id bigint PRIMARY KEY, movie_id bigint, FOREIGN KEY (movie_id) REFERENCES movies (id));`,
+			want: true,
+		},
+		{
+			name: "foreign key with index created later in the same migration",
+			sql: `CREATE TABLE ratings (id bigint PRIMARY KEY, movie_id bigint REFERENCES movies (id));
+CREATE INDEX ratings_movie_id_idx ON ratings (movie_id);`,
+			statement: 0,
+			want:      false,
+		},
+		{
+			name:    "foreign key with index created in a previous migration",
+			history: `CREATE INDEX ratings_movie_id_idx ON ratings (movie_id, created_at);`,
+			sql:     `ALTER TABLE ratings ADD CONSTRAINT ratings_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES movies (id);`,
+			want:    false,
+		},
+		{
+			name:    "foreign key column is not the leading index column",
+			history: `CREATE INDEX ratings_created_at_movie_id_idx ON ratings (created_at, movie_id);`,
+			sql:     `ALTER TABLE ratings ADD CONSTRAINT ratings_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES movies (id);`,
+			want:    true,
+		},
+		{
+			name:    "foreign key with dropped index",
+			history: `CREATE INDEX ratings_movie_id_idx ON ratings (movie_id);`,
+			sql: `DROP INDEX ratings_movie_id_idx;
+ALTER TABLE ratings ADD CONSTRAINT ratings_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES movies (id);`,
+			statement: 1,
+			want:      true,
+		},
+		{
+			name:      "multi-column foreign key covered by primary key in any order",
+			sql:       `CREATE TABLE ratings (user_id bigint, movie_id bigint, PRIMARY KEY (user_id, movie_id), FOREIGN KEY (movie_id, user_id) REFERENCES user_movies (movie_id, user_id));`,
+			statement: 0,
+			want:      false,
+		},
+		{
+			name: "foreign key covered by unique column",
+			sql:  `CREATE TABLE profiles (id bigint PRIMARY KEY, user_id bigint UNIQUE REFERENCES users (id));`,
+			want: false,
+		},
+		{
+			name: "added column with foreign key",
+			sql:  `ALTER TABLE ratings ADD COLUMN user_id bigint REFERENCES users (id);`,
+			want: true,
+		},
+		{
+			name: "expression index does not cover foreign key",
+			sql: `CREATE INDEX ratings_movie_id_idx ON ratings ((movie_id + 0));
+ALTER TABLE ratings ADD CONSTRAINT ratings_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES movies (id);`,
+			statement: 1,
+			want:      true,
+		},
+		{
+			name: "statement without foreign key",
+			sql:  `CREATE TABLE ratings (id bigint PRIMARY KEY, movie_id bigint);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parse := func(sql string) []*pg_query.Node {
+				var nodes []*pg_query.Node
+				if sql == "" {
+					return nodes
+				}
+				for _, statement := range strings.Split(sql, "\n") {
+					nodes = append(nodes, parseStatement(t, statement))
+				}
+				return nodes
+			}
+			allNodes := parse(tt.sql)
+			r := ForeignKeyRequiresIndex{}
+			assert.Equal(t, tt.want, r.Process(allNodes[tt.statement], MigrationContext{
+				AllStatements: allNodes,
+				Catalog:       newCatalog(parse(tt.history)),
+			}))
+		})
+	}
+}
+
+func TestForeignKeyRequiresIndex_Explain(t *testing.T) {
+	t.Parallel()
+
+	node := parseStatement(t, `CREATE TABLE ratings (user_id bigint REFERENCES users (id), movie_id bigint REFERENCES movies (id));`)
+	r := ForeignKeyRequiresIndex{}
+	assert.Equal(t, "No index found with leading columns: ratings (user_id); ratings (movie_id)",
+		r.Explain(node, MigrationContext{AllStatements: []*pg_query.Node{node}}))
+}
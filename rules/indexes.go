@@ -2,10 +2,13 @@ package rules
 
 import (
 	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/locks"
 	"strings"
 )
 
-type CreateIndexNonConcurrently struct{}
+type CreateIndexNonConcurrently struct {
+	NewTableExemption `yaml:",inline"`
+}
 
 func (r CreateIndexNonConcurrently) Alias() string {
 	return HighAvailabilityRule("avoid-non-concurrent-index-creation")
@@ -15,7 +18,17 @@ func (r CreateIndexNonConcurrently) Documentation() string {
 	return "Non-concurrent index creation will not allow writes while the index is being built."
 }
 
-func (r CreateIndexNonConcurrently) Process(node *pg_query.Node, _ []*pg_query.Node, _ bool) bool {
+func (r CreateIndexNonConcurrently) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r CreateIndexNonConcurrently) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
 	indexStmt := node.GetIndexStmt()
 	if indexStmt == nil {
 		return false
@@ -23,7 +36,9 @@ func (r CreateIndexNonConcurrently) Process(node *pg_query.Node, _ []*pg_query.N
 	return !indexStmt.Concurrent
 }
 
-type DropIndexNonConcurrently struct{}
+type DropIndexNonConcurrently struct {
+	NewTableExemption `yaml:",inline"`
+}
 
 func (r DropIndexNonConcurrently) Alias() string {
 	return HighAvailabilityRule("avoid-non-concurrent-index-drop")
@@ -33,7 +48,17 @@ func (r DropIndexNonConcurrently) Documentation() string {
 	return "Non-concurrent index drop will not allow writes while the index is being built."
 }
 
-func (r DropIndexNonConcurrently) Process(node *pg_query.Node, _ []*pg_query.Node, _ bool) bool {
+func (r DropIndexNonConcurrently) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r DropIndexNonConcurrently) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
 	dropStmt := node.GetDropStmt()
 	if dropStmt == nil {
 		return false
@@ -44,6 +69,46 @@ func (r DropIndexNonConcurrently) Process(node *pg_query.Node, _ []*pg_query.Nod
 	return !dropStmt.GetConcurrent()
 }
 
+type ReindexNonConcurrently struct {
+	NewTableExemption `yaml:",inline"`
+}
+
+func (r ReindexNonConcurrently) Alias() string {
+	return HighAvailabilityRule("avoid-non-concurrent-reindex")
+}
+
+func (r ReindexNonConcurrently) Documentation() string {
+	return "Non-concurrent REINDEX will not allow writes to the table and blocks reads that use the index while it is being rebuilt. " +
+		"Since PostgreSQL 12, use REINDEX ... CONCURRENTLY instead, outside a transaction."
+}
+
+// REINDEX CONCURRENTLY is available since PostgreSQL 12
+func (r ReindexNonConcurrently) Explain(_ *pg_query.Node, ctx MigrationContext) string {
+	if ctx.PostgresVersion != 0 && ctx.PostgresVersion < 12 {
+		return "REINDEX CONCURRENTLY is not available before PostgreSQL 12: " +
+			"build a new index with CREATE INDEX CONCURRENTLY and drop the old one with DROP INDEX CONCURRENTLY instead."
+	}
+	return "Use REINDEX ... CONCURRENTLY instead, outside a transaction."
+}
+
+func (r ReindexNonConcurrently) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r ReindexNonConcurrently) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	reindexStmt := node.GetReindexStmt()
+	if reindexStmt == nil {
+		return false
+	}
+	return !locks.HasOption(reindexStmt.GetParams(), "concurrently")
+}
+
 type IndexOperationNotIdempotent struct{}
 
 func (r IndexOperationNotIdempotent) Alias() string {
@@ -54,8 +119,8 @@ func (r IndexOperationNotIdempotent) Documentation() string {
 	return "Creating/removing an index outside of a transaction without an IF (NOT) EXISTS option can cause a migration to not be idempotent."
 }
 
-func (r IndexOperationNotIdempotent) Process(node *pg_query.Node, _ []*pg_query.Node, inTransaction bool) bool {
-	if inTransaction {
+func (r IndexOperationNotIdempotent) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if ctx.InTransaction {
 		return false
 	}
 
@@ -83,7 +148,7 @@ func (r IndexMustBeNamed) Documentation() string {
 	return "Indexes should be explicitly named."
 }
 
-func (r IndexMustBeNamed) Process(node *pg_query.Node, _ []*pg_query.Node, _ bool) bool {
+func (r IndexMustBeNamed) Process(node *pg_query.Node, _ MigrationContext) bool {
 	createStmt := node.GetIndexStmt()
 	if createStmt == nil {
 		return false
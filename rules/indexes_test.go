@@ -4,6 +4,7 @@ import (
 	pg_query "github.com/pganalyze/pg_query_go/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"pgsafemigrate/catalog"
 	"testing"
 )
 
@@ -68,7 +69,7 @@ func TestCreateIndexNonConcurrently_Process(t *testing.T) {
 			r := CreateIndexNonConcurrently{}
 			node := parseStatement(t, tt.args.statement)
 
-			tt.assertionFunc(t, r.Process(node, nil, tt.args.inTransaction))
+			tt.assertionFunc(t, r.Process(node, MigrationContext{InTransaction: tt.args.inTransaction}))
 		})
 	}
 }
@@ -81,3 +82,62 @@ func parseStatement(t *testing.T, sql string) *pg_query.Node {
 
 	return node.GetStmts()[0].Stmt
 }
+
+// newCatalog returns the catalog built from the given statements.
+func newCatalog(statements []*pg_query.Node) *catalog.Catalog {
+	cat := catalog.New()
+	for _, n := range statements {
+		cat.Apply(n)
+	}
+	return cat
+}
+
+func TestReindexNonConcurrently_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{name: "REINDEX TABLE", sql: `REINDEX TABLE movies;`, want: true},
+		{name: "REINDEX INDEX", sql: `REINDEX INDEX movies_title_idx;`, want: true},
+		{name: "REINDEX INDEX CONCURRENTLY", sql: `REINDEX INDEX CONCURRENTLY movies_title_idx;`, want: false},
+		{name: "REINDEX with CONCURRENTLY option", sql: `REINDEX (CONCURRENTLY true) TABLE movies;`, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, ReindexNonConcurrently{}.Process(parseStatement(t, tt.sql), MigrationContext{}))
+		})
+	}
+}
+
+func TestReindexNonConcurrently_Explain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version int
+		want    string
+	}{
+		{name: "unknown version", want: "Use REINDEX ... CONCURRENTLY instead, outside a transaction."},
+		{name: "PostgreSQL 12", version: 12, want: "Use REINDEX ... CONCURRENTLY instead, outside a transaction."},
+		{
+			name:    "PostgreSQL 11",
+			version: 11,
+			want:    "REINDEX CONCURRENTLY is not available before PostgreSQL 12: build a new index with CREATE INDEX CONCURRENTLY and drop the old one with DROP INDEX CONCURRENTLY instead.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			node := parseStatement(t, `REINDEX TABLE movies;`)
+			assert.Equal(t, tt.want, ReindexNonConcurrently{}.Explain(node, MigrationContext{PostgresVersion: tt.version}))
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/locks"
+)
+
+// acquiresTableLock reports whether the statement acquires a lock that blocks writes or reads on a table.
+// Renames only lock a table when they rename a table, view, index, column or constraint, not a type or a schema.
+func acquiresTableLock(node *pg_query.Node) bool {
+	for _, lock := range locks.Acquired(node, nil) {
+		if lock.Mode.BlocksWrites() {
+			return true
+		}
+	}
+	return false
+}
+
+// acquiresAccessExclusiveLock reports whether the statement acquires an ACCESS EXCLUSIVE lock on a table,
+// which blocks all reads and writes until the end of the transaction.
+func acquiresAccessExclusiveLock(node *pg_query.Node) bool {
+	for _, lock := range locks.Acquired(node, nil) {
+		if lock.Mode == locks.AccessExclusive {
+			return true
+		}
+	}
+	return false
+}
+
+// lockedRelations returns the names of the relations locked by the statement, as listed by locks.Acquired,
+// including the tables referenced by foreign keys. Indexes are resolved to their table using the schema before the statement.
+func lockedRelations(node *pg_query.Node, ctx MigrationContext) []string {
+	transaction, _, index := transactionOf(node, ctx)
+	if index < 0 {
+		return nil
+	}
+	var relations []string
+	for _, lock := range transaction.Statements[index].Locks {
+		// REINDEX INDEX also locks the index itself, along with its table
+		if reindexStmt := node.GetReindexStmt(); reindexStmt.GetKind() == pg_query.ReindexObjectType_REINDEX_OBJECT_INDEX &&
+			lock.Relation == reindexStmt.GetRelation().GetRelname() {
+			continue
+		}
+		relations = append(relations, lock.Relation)
+	}
+	return relations
+}
+
+// NewTableExemption is embedded by the rules that report statements acquiring locks.
+// Tables created earlier in the same migration cannot be in use yet, so locking them is harmless
+// and the statements on them are not reported, unless the check-new-tables setting is enabled.
+type NewTableExemption struct {
+	CheckNewTables bool `yaml:"check-new-tables"`
+}
+
+func (e NewTableExemption) reportsLocks() {}
+
+// exempt reports whether all the relations locked by the statement have been created before it in the same migration.
+func (e NewTableExemption) exempt(node *pg_query.Node, ctx MigrationContext) bool {
+	if e.CheckNewTables {
+		return false
+	}
+	relations := lockedRelations(node, ctx)
+	if len(relations) == 0 {
+		return false
+	}
+	for _, relation := range relations {
+		if !ctx.CreatedInMigration(relation, node) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewTableExemption(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		rule           Rule
+		sql            string
+		checkNewTables bool
+		want           bool
+	}{
+		{
+			name: "index on table created in the same migration",
+			rule: CreateIndexNonConcurrently{},
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);`,
+			want: false,
+		},
+		{
+			name: "index on table created in the same migration with check-new-tables",
+			rule: CreateIndexNonConcurrently{},
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);`,
+			checkNewTables: true,
+			want:           true,
+		},
+		{
+			name: "index on existing table",
+			rule: CreateIndexNonConcurrently{},
+			sql: `CREATE TABLE directors (id bigint PRIMARY KEY);
+CREATE INDEX movies_title_idx ON movies (title);`,
+			want: true,
+		},
+		{
+			name: "dropped index of table created in the same migration",
+			rule: DropIndexNonConcurrently{},
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);
+DROP INDEX movies_title_idx;`,
+			want: false,
+		},
+		{
+			name: "dropped index of unknown table",
+			rule: DropIndexNonConcurrently{},
+			sql:  `DROP INDEX movies_title_idx;`,
+			want: true,
+		},
+		{
+			name: "required column on table created in the same migration",
+			rule: RequiredColumn{},
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY);
+ALTER TABLE movies ADD COLUMN title text NOT NULL;`,
+			want: false,
+		},
+		{
+			name: "not null column on table created in the same migration",
+			rule: ColumnSetNotNull{},
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+ALTER TABLE movies ALTER COLUMN title SET NOT NULL;`,
+			want: false,
+		},
+		{
+			name: "lock timeout on table created in the same migration",
+			rule: LockTimeoutRequired{},
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY);
+ALTER TABLE movies ADD COLUMN title text;`,
+			want: false,
+		},
+		{
+			name: "foreign key from a table created in the same migration to an existing table",
+			rule: LockTimeoutRequired{},
+			sql: `CREATE TABLE payments (id bigint PRIMARY KEY, account_id bigint);
+ALTER TABLE payments ADD CONSTRAINT payments_account_id_fkey FOREIGN KEY (account_id) REFERENCES accounts (id);`,
+			want: true,
+		},
+		{
+			name: "reindex of an index of a table created in the same migration",
+			rule: ReindexNonConcurrently{},
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY, title text);
+CREATE INDEX movies_title_idx ON movies (title);
+REINDEX INDEX movies_title_idx;`,
+			want: false,
+		},
+		{
+			name: "table created after the statement",
+			rule: RequiredColumn{},
+			sql: `ALTER TABLE movies ADD COLUMN title text NOT NULL;
+CREATE TABLE movies (id bigint PRIMARY KEY);`,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var settings yaml.Node
+			require.NoError(t, yaml.Unmarshal([]byte("check-new-tables: "+strconv.FormatBool(tt.checkNewTables)), &settings))
+			rule, err := tt.rule.(Configurable).Configure(settings.Decode)
+			require.NoError(t, err)
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			// The statement under test is the last one, unless the table is created afterwards
+			node := allNodes[len(allNodes)-1]
+			if node.GetCreateStmt() != nil {
+				node = allNodes[0]
+			}
+			assert.Equal(t, tt.want, rule.Process(node, MigrationContext{AllStatements: allNodes, InTransaction: true}))
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/locks"
+)
+
+// VacuumFull - VACUUM FULL rewrites the table while holding an ACCESS EXCLUSIVE lock.
+type VacuumFull struct {
+	NewTableExemption `yaml:",inline"`
+}
+
+func (r VacuumFull) Alias() string {
+	return HighAvailabilityRule("avoid-vacuum-full")
+}
+
+func (r VacuumFull) Documentation() string {
+	return "VACUUM FULL rewrites the entire table and its indexes while holding an ACCESS EXCLUSIVE lock, " +
+		"blocking all reads and writes. Consider an online alternative such as pg_repack."
+}
+
+func (r VacuumFull) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r VacuumFull) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	return isVacuumFull(node)
+}
+
+// Cluster - CLUSTER rewrites the table while holding an ACCESS EXCLUSIVE lock.
+type Cluster struct {
+	NewTableExemption `yaml:",inline"`
+}
+
+func (r Cluster) Alias() string {
+	return HighAvailabilityRule("avoid-cluster")
+}
+
+func (r Cluster) Documentation() string {
+	return "CLUSTER rewrites the entire table and its indexes while holding an ACCESS EXCLUSIVE lock, " +
+		"blocking all reads and writes. Consider an online alternative such as pg_repack."
+}
+
+func (r Cluster) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r Cluster) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	return node.GetClusterStmt() != nil
+}
+
+// VacuumInTransaction - VACUUM cannot be executed inside a transaction block.
+type VacuumInTransaction struct{}
+
+func (r VacuumInTransaction) Alias() string {
+	return TransactionRule("vacuum-cannot-be-executed-in-transaction")
+}
+
+func (r VacuumInTransaction) Documentation() string {
+	return "VACUUM cannot be executed inside a transaction."
+}
+
+func (r VacuumInTransaction) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if !ctx.InTransaction {
+		return false
+	}
+	return node.GetVacuumStmt().GetIsVacuumcmd()
+}
+
+func isVacuumFull(node *pg_query.Node) bool {
+	vacuumStmt := node.GetVacuumStmt()
+	if vacuumStmt == nil || !vacuumStmt.GetIsVacuumcmd() {
+		return false
+	}
+	return locks.HasOption(vacuumStmt.GetOptions(), "full")
+}
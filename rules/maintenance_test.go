@@ -0,0 +1,60 @@
+package rules
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVacuumFull_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{name: "VACUUM FULL", sql: `VACUUM FULL movies;`, want: true},
+		{name: "VACUUM with FULL option", sql: `VACUUM (FULL, ANALYZE) movies;`, want: true},
+		{name: "VACUUM with disabled FULL option", sql: `VACUUM (FULL false) movies;`, want: false},
+		{name: "VACUUM", sql: `VACUUM movies;`, want: false},
+		{name: "ANALYZE", sql: `ANALYZE movies;`, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, VacuumFull{}.Process(parseStatement(t, tt.sql), MigrationContext{}))
+		})
+	}
+}
+
+func TestCluster_Process(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Cluster{}.Process(parseStatement(t, `CLUSTER movies USING movies_title_idx;`), MigrationContext{}))
+	assert.False(t, Cluster{}.Process(parseStatement(t, `VACUUM movies;`), MigrationContext{}))
+}
+
+func TestVacuumInTransaction_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		sql           string
+		inTransaction bool
+		want          bool
+	}{
+		{name: "VACUUM in transaction", sql: `VACUUM movies;`, inTransaction: true, want: true},
+		{name: "VACUUM without transaction", sql: `VACUUM movies;`, inTransaction: false, want: false},
+		{name: "ANALYZE in transaction", sql: `ANALYZE movies;`, inTransaction: true, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, VacuumInTransaction{}.Process(parseStatement(t, tt.sql), MigrationContext{InTransaction: tt.inTransaction}))
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// Kinds of named database objects
+const (
+	objectIndex      = "index"
+	objectPrimaryKey = "primary key"
+	objectForeignKey = "foreign key"
+	objectCheck      = "check constraint"
+	objectUnique     = "unique constraint"
+	objectConstraint = "constraint"
+	objectTable      = "table"
+	objectColumn     = "column"
+	objectSequence   = "sequence"
+	objectView       = "view"
+	objectSchema     = "schema"
+	objectType       = "type"
+)
+
+// namedObject is a database object that is created or renamed by a statement.
+// The table, columns and referenced table are empty when they cannot be determined from the statement.
+type namedObject struct {
+	kind     string
+	name     string
+	table    string
+	columns  []string
+	refTable string
+}
+
+// namedObjects returns the objects that are explicitly named by the statement.
+// Objects named automatically by PostgreSQL, e.g. constraints without a name, are not included.
+func namedObjects(node *pg_query.Node) []namedObject {
+	var objects []namedObject
+	addConstraint := func(table string, constraint *pg_query.Constraint, columns []string) {
+		if constraint.GetConname() == "" {
+			return
+		}
+		o := namedObject{name: constraint.GetConname(), table: table, columns: columns}
+		switch constraint.GetContype() {
+		case pg_query.ConstrType_CONSTR_PRIMARY:
+			o.kind = objectPrimaryKey
+			if len(constraint.GetKeys()) > 0 {
+				o.columns = stringValues(constraint.GetKeys())
+			}
+		case pg_query.ConstrType_CONSTR_UNIQUE:
+			o.kind = objectUnique
+			if len(constraint.GetKeys()) > 0 {
+				o.columns = stringValues(constraint.GetKeys())
+			}
+		case pg_query.ConstrType_CONSTR_FOREIGN:
+			o.kind = objectForeignKey
+			if len(constraint.GetFkAttrs()) > 0 {
+				o.columns = stringValues(constraint.GetFkAttrs())
+			}
+			o.refTable = constraint.GetPktable().GetRelname()
+		case pg_query.ConstrType_CONSTR_CHECK:
+			o.kind = objectCheck
+		default:
+			o.kind = objectConstraint
+		}
+		objects = append(objects, o)
+	}
+	addColumn := func(table string, columnDef *pg_query.ColumnDef) {
+		objects = append(objects, namedObject{kind: objectColumn, name: columnDef.GetColname(), table: table})
+		for _, c := range columnDef.GetConstraints() {
+			addConstraint(table, c.GetConstraint(), []string{columnDef.GetColname()})
+		}
+	}
+
+	switch {
+	case node.GetCreateStmt() != nil:
+		table := node.GetCreateStmt().GetRelation().GetRelname()
+		objects = append(objects, namedObject{kind: objectTable, name: table, table: table})
+		for _, elt := range node.GetCreateStmt().GetTableElts() {
+			if columnDef := elt.GetColumnDef(); columnDef != nil {
+				addColumn(table, columnDef)
+			}
+			if constraint := elt.GetConstraint(); constraint != nil {
+				addConstraint(table, constraint, nil)
+			}
+		}
+	case node.GetAlterTableStmt() != nil:
+		table := node.GetAlterTableStmt().GetRelation().GetRelname()
+		for _, cmd := range node.GetAlterTableStmt().GetCmds() {
+			alterTableCmd := cmd.GetAlterTableCmd()
+			switch alterTableCmd.GetSubtype() {
+			case pg_query.AlterTableType_AT_AddColumn:
+				addColumn(table, alterTableCmd.GetDef().GetColumnDef())
+			case pg_query.AlterTableType_AT_AddConstraint:
+				addConstraint(table, alterTableCmd.GetDef().GetConstraint(), nil)
+			}
+		}
+	case node.GetIndexStmt() != nil:
+		indexStmt := node.GetIndexStmt()
+		if indexStmt.GetIdxname() == "" {
+			break
+		}
+		var columns []string
+		for _, param := range indexStmt.GetIndexParams() {
+			if name := param.GetIndexElem().GetName(); name != "" {
+				columns = append(columns, name)
+			}
+		}
+		objects = append(objects, namedObject{
+			kind:    objectIndex,
+			name:    indexStmt.GetIdxname(),
+			table:   indexStmt.GetRelation().GetRelname(),
+			columns: columns,
+		})
+	case node.GetCreateSeqStmt() != nil:
+		objects = append(objects, namedObject{kind: objectSequence, name: node.GetCreateSeqStmt().GetSequence().GetRelname()})
+	case node.GetViewStmt() != nil:
+		objects = append(objects, namedObject{kind: objectView, name: node.GetViewStmt().GetView().GetRelname()})
+	case node.GetCreateTableAsStmt() != nil:
+		kind := objectTable
+		if node.GetCreateTableAsStmt().GetObjtype() == pg_query.ObjectType_OBJECT_MATVIEW {
+			kind = objectView
+		}
+		name := node.GetCreateTableAsStmt().GetInto().GetRel().GetRelname()
+		o := namedObject{kind: kind, name: name}
+		if kind == objectTable {
+			o.table = name
+		}
+		objects = append(objects, o)
+	case node.GetCreateSchemaStmt() != nil:
+		objects = append(objects, namedObject{kind: objectSchema, name: node.GetCreateSchemaStmt().GetSchemaname()})
+	case node.GetCreateEnumStmt() != nil:
+		names := stringValues(node.GetCreateEnumStmt().GetTypeName())
+		if len(names) > 0 {
+			objects = append(objects, namedObject{kind: objectType, name: names[len(names)-1]})
+		}
+	case node.GetRenameStmt() != nil:
+		renameStmt := node.GetRenameStmt()
+		o := namedObject{name: renameStmt.GetNewname()}
+		switch renameStmt.GetRenameType() {
+		case pg_query.ObjectType_OBJECT_TABLE:
+			o.kind, o.table = objectTable, renameStmt.GetNewname()
+		case pg_query.ObjectType_OBJECT_COLUMN:
+			o.kind, o.table = objectColumn, renameStmt.GetRelation().GetRelname()
+		case pg_query.ObjectType_OBJECT_INDEX:
+			o.kind = objectIndex
+		case pg_query.ObjectType_OBJECT_TABCONSTRAINT:
+			// The constraint type is not known
+			o.kind, o.table = objectConstraint, renameStmt.GetRelation().GetRelname()
+		case pg_query.ObjectType_OBJECT_SEQUENCE:
+			o.kind = objectSequence
+		case pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW:
+			o.kind = objectView
+		case pg_query.ObjectType_OBJECT_SCHEMA:
+			o.kind = objectSchema
+		case pg_query.ObjectType_OBJECT_TYPE:
+			o.kind = objectType
+		default:
+			return nil
+		}
+		objects = append(objects, o)
+	}
+	return objects
+}
+
+// NamingConvention - Object names must match the configured templates.
+type NamingConvention struct {
+	Indexes     string `yaml:"indexes"`
+	PrimaryKeys string `yaml:"primary-keys"`
+	ForeignKeys string `yaml:"foreign-keys"`
+	Checks      string `yaml:"checks"`
+	Uniques     string `yaml:"uniques"`
+	Tables      string `yaml:"tables"`
+	Columns     string `yaml:"columns"`
+	Sequences   string `yaml:"sequences"`
+}
+
+func (r NamingConvention) Alias() string {
+	return MaintainabilityRule("naming-convention")
+}
+
+func (r NamingConvention) Documentation() string {
+	return "Names of indexes, constraints, tables, columns and sequences must match the configured templates. " +
+		"Templates are regular expressions that must match the entire name, where {table}, {columns} and {reftable} are replaced with " +
+		"the table name, the column names joined with underscores and the referenced table name respectively. " +
+		"Nothing is reported unless templates are configured."
+}
+
+func (r NamingConvention) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	for _, template := range []string{r.Indexes, r.PrimaryKeys, r.ForeignKeys, r.Checks, r.Uniques, r.Tables, r.Columns, r.Sequences} {
+		if template == "" {
+			continue
+		}
+		if _, err := regexp.Compile(expandNamingTemplate(template, namedObject{})); err != nil {
+			return nil, fmt.Errorf("invalid naming template %q: %w", template, err)
+		}
+	}
+	return r, nil
+}
+
+func (r NamingConvention) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return len(r.violations(node)) > 0
+}
+
+func (r NamingConvention) Explain(node *pg_query.Node, _ MigrationContext) string {
+	var descriptions []string
+	for _, o := range r.violations(node) {
+		descriptions = append(descriptions, fmt.Sprintf("%s %q does not match %q", o.kind, o.name, r.template(o.kind)))
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+func (r NamingConvention) template(kind string) string {
+	switch kind {
+	case objectIndex:
+		return r.Indexes
+	case objectPrimaryKey:
+		return r.PrimaryKeys
+	case objectForeignKey:
+		return r.ForeignKeys
+	case objectCheck:
+		return r.Checks
+	case objectUnique:
+		return r.Uniques
+	case objectTable:
+		return r.Tables
+	case objectColumn:
+		return r.Columns
+	case objectSequence:
+		return r.Sequences
+	}
+	return ""
+}
+
+func (r NamingConvention) violations(node *pg_query.Node) []namedObject {
+	var violations []namedObject
+	for _, o := range namedObjects(node) {
+		template := r.template(o.kind)
+		if template == "" {
+			continue
+		}
+		// Templates that refer to values that cannot be determined from the statement are not checked
+		if (strings.Contains(template, "{table}") && o.table == "") ||
+			(strings.Contains(template, "{columns}") && len(o.columns) == 0) ||
+			(strings.Contains(template, "{reftable}") && o.refTable == "") {
+			continue
+		}
+		pattern, err := regexp.Compile(expandNamingTemplate(template, o))
+		if err != nil {
+			continue
+		}
+		if !pattern.MatchString(o.name) {
+			violations = append(violations, o)
+		}
+	}
+	return violations
+}
+
+// expandNamingTemplate replaces the template placeholders with the quoted object properties
+// and anchors the resulting regular expression.
+func expandNamingTemplate(template string, o namedObject) string {
+	replacer := strings.NewReplacer(
+		"{table}", regexp.QuoteMeta(o.table),
+		"{columns}", regexp.QuoteMeta(strings.Join(o.columns, "_")),
+		"{reftable}", regexp.QuoteMeta(o.refTable),
+	)
+	return "^(?:" + replacer.Replace(template) + ")$"
+}
+
+// maxIdentifierLength is the maximum identifier length in bytes, NAMEDATALEN - 1.
+const maxIdentifierLength = 63
+
+// IdentifierTooLong - Identifiers longer than 63 bytes are silently truncated.
+type IdentifierTooLong struct{}
+
+func (r IdentifierTooLong) Alias() string {
+	return MaintainabilityRule("identifier-too-long")
+}
+
+// https://www.postgresql.org/docs/current/sql-syntax-lexical.html#SQL-SYNTAX-IDENTIFIERS
+func (r IdentifierTooLong) Documentation() string {
+	return "Identifiers longer than 63 bytes are truncated by PostgreSQL with a notice. The object is created with a different name than the one in the migration, " +
+		"which breaks statements that refer to the full name, e.g. IF NOT EXISTS checks that are expected to skip an existing object."
+}
+
+// The parser truncates identifiers, so they are found in the statement text instead of the parse tree.
+func (r IdentifierTooLong) Process(_ *pg_query.Node, ctx MigrationContext) bool {
+	return len(longIdentifiers(ctx.RawSQL)) > 0
+}
+
+func (r IdentifierTooLong) Explain(_ *pg_query.Node, ctx MigrationContext) string {
+	var descriptions []string
+	for _, identifier := range longIdentifiers(ctx.RawSQL) {
+		descriptions = append(descriptions, fmt.Sprintf("%q (%d bytes)", identifier, len(identifier)))
+	}
+	return "Identifiers: " + strings.Join(descriptions, ", ")
+}
+
+// longIdentifiers returns the identifiers of the SQL statements that are longer than the maximum identifier length.
+func longIdentifiers(sql string) []string {
+	result, err := pg_query.Scan(sql)
+	if err != nil {
+		return nil
+	}
+	var identifiers []string
+	for _, token := range result.GetTokens() {
+		if token.GetToken() != pg_query.Token_IDENT {
+			continue
+		}
+		identifier := sql[token.GetStart():token.GetEnd()]
+		if strings.HasPrefix(identifier, `"`) {
+			identifier = strings.ReplaceAll(strings.Trim(identifier, `"`), `""`, `"`)
+		}
+		if len(identifier) > maxIdentifierLength {
+			identifiers = append(identifiers, identifier)
+		}
+	}
+	return identifiers
+}
@@ -0,0 +1,201 @@
+package rules
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestNamingConvention_Process(t *testing.T) {
+	t.Parallel()
+
+	convention := NamingConvention{
+		Indexes:     "idx_{table}_{columns}",
+		PrimaryKeys: "pk_{table}",
+		ForeignKeys: "fk_{table}_{reftable}",
+		Checks:      "chk_{table}_[a-z_]+",
+		Uniques:     "uq_{table}_{columns}",
+		Tables:      "[a-z][a-z0-9_]*",
+		Columns:     "[a-z][a-z0-9_]*",
+		Sequences:   "seq_[a-z_]+",
+	}
+	tests := []struct {
+		name       string
+		convention NamingConvention
+		sql        string
+		want       bool
+	}{
+		{
+			name:       "index matches template",
+			convention: convention,
+			sql:        `CREATE INDEX idx_movies_title_year ON movies (title, year);`,
+			want:       false,
+		},
+		{
+			name:       "index does not match template",
+			convention: convention,
+			sql:        `CREATE INDEX movies_title_idx ON movies (title);`,
+			want:       true,
+		},
+		{
+			name:       "unnamed index is not checked",
+			convention: convention,
+			sql:        `CREATE INDEX ON movies (title);`,
+			want:       false,
+		},
+		{
+			name:       "constraints match templates",
+			convention: convention,
+			sql:        `CREATE TABLE ratings (id bigint CONSTRAINT pk_ratings PRIMARY KEY, movie_id bigint CONSTRAINT fk_ratings_movies REFERENCES movies (id), CONSTRAINT chk_ratings_positive CHECK (id > 0));`,
+			want:       false,
+		},
+		{
+			name:       "foreign key does not match template",
+			convention: convention,
+			sql:        `ALTER TABLE ratings ADD CONSTRAINT ratings_movie_id_fkey FOREIGN KEY (movie_id) REFERENCES movies (id);`,
+			want:       true,
+		},
+		{
+			name:       "unique constraint does not match template",
+			convention: convention,
+			sql:        `ALTER TABLE movies ADD CONSTRAINT uq_movies_title UNIQUE (title, year);`,
+			want:       true,
+		},
+		{
+			name:       "unnamed constraints are not checked",
+			convention: convention,
+			sql:        `CREATE TABLE ratings (id bigint PRIMARY KEY, movie_id bigint REFERENCES movies (id));`,
+			want:       false,
+		},
+		{
+			name:       "column does not match template",
+			convention: convention,
+			sql:        `ALTER TABLE movies ADD COLUMN "ReleasedAt" timestamptz;`,
+			want:       true,
+		},
+		{
+			name:       "renamed table does not match template",
+			convention: convention,
+			sql:        `ALTER TABLE movies RENAME TO "Films";`,
+			want:       true,
+		},
+		{
+			name:       "sequence does not match template",
+			convention: convention,
+			sql:        `CREATE SEQUENCE movies_id_seq;`,
+			want:       true,
+		},
+		{
+			name:       "renamed index is not checked when the table is unknown",
+			convention: convention,
+			sql:        `ALTER INDEX movies_title_idx RENAME TO title_idx;`,
+			want:       false,
+		},
+		{
+			name: "no templates configured",
+			sql:  `CREATE INDEX movies_title_idx ON movies (title);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, tt.convention.Process(parseStatement(t, tt.sql), MigrationContext{}))
+		})
+	}
+}
+
+func TestNamingConvention_Configure(t *testing.T) {
+	t.Parallel()
+
+	_, err := NamingConvention{}.Configure(func(v any) error {
+		v.(*NamingConvention).Indexes = "idx_{table}_{columns}"
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = NamingConvention{}.Configure(func(v any) error {
+		v.(*NamingConvention).Indexes = "idx_({table}"
+		return nil
+	})
+	assert.Error(t, err)
+}
+
+func TestNamingConvention_Explain(t *testing.T) {
+	t.Parallel()
+
+	r := NamingConvention{Indexes: "idx_{table}_{columns}"}
+	assert.Equal(t, `index "movies_title_idx" does not match "idx_{table}_{columns}"`,
+		r.Explain(parseStatement(t, `CREATE INDEX movies_title_idx ON movies (title);`), MigrationContext{}))
+}
+
+func TestIdentifierTooLong_Process(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("a", 64)
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "index name with 63 bytes",
+			sql:  `CREATE INDEX IF NOT EXISTS ` + long[:63] + ` ON movies (title);`,
+			want: false,
+		},
+		{
+			name: "index name with 64 bytes",
+			sql:  `CREATE INDEX IF NOT EXISTS ` + long + ` ON movies (title);`,
+			want: true,
+		},
+		{
+			name: "multibyte column name",
+			sql:  `ALTER TABLE movies ADD COLUMN "` + strings.Repeat("é", 32) + `" text;`,
+			want: true,
+		},
+		{
+			name: "constraint name",
+			sql:  `ALTER TABLE movies ADD CONSTRAINT ` + long + ` CHECK (year > 1800);`,
+			want: true,
+		},
+		{
+			name: "table name",
+			sql:  `CREATE TABLE ` + long + ` (id bigint PRIMARY KEY);`,
+			want: true,
+		},
+		{
+			name: "renamed table",
+			sql:  `ALTER TABLE movies RENAME TO ` + long + `;`,
+			want: true,
+		},
+		{
+			name: "quoted identifier with escaped quotes",
+			sql:  `ALTER TABLE movies ADD COLUMN "` + strings.Repeat(`a""`, 31) + `" text;`,
+			want: false,
+		},
+		{
+			name: "short identifiers",
+			sql:  `CREATE TABLE movies (id bigint PRIMARY KEY, title text);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := IdentifierTooLong{}
+			assert.Equal(t, tt.want, r.Process(parseStatement(t, tt.sql), MigrationContext{RawSQL: tt.sql}))
+		})
+	}
+}
+
+func TestIdentifierTooLong_Explain(t *testing.T) {
+	t.Parallel()
+
+	sql := `CREATE INDEX IF NOT EXISTS ` + strings.Repeat("a", 64) + ` ON movies (title);`
+	r := IdentifierTooLong{}
+	assert.Equal(t, `Identifiers: "`+strings.Repeat("a", 64)+`" (64 bytes)`,
+		r.Explain(parseStatement(t, sql), MigrationContext{RawSQL: sql}))
+}
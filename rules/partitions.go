@@ -0,0 +1,49 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// DetachPartitionNonConcurrently - Detaching a partition blocks all queries on the partitioned table.
+type DetachPartitionNonConcurrently struct {
+	NewTableExemption `yaml:",inline"`
+}
+
+func (r DetachPartitionNonConcurrently) Alias() string {
+	return HighAvailabilityRule("avoid-non-concurrent-detach-partition")
+}
+
+// https://www.postgresql.org/docs/current/sql-altertable.html#SQL-ALTERTABLE-DETACH-PARTITION
+func (r DetachPartitionNonConcurrently) Documentation() string {
+	return "Detaching a partition acquires an ACCESS EXCLUSIVE lock on the partitioned table, blocking all reads and writes. " +
+		"Since PostgreSQL 14, use DETACH PARTITION ... CONCURRENTLY instead, outside a transaction."
+}
+
+// DETACH PARTITION CONCURRENTLY is available since PostgreSQL 14
+func (r DetachPartitionNonConcurrently) Explain(_ *pg_query.Node, ctx MigrationContext) string {
+	if ctx.PostgresVersion != 0 && ctx.PostgresVersion < 14 {
+		return "DETACH PARTITION CONCURRENTLY is not available before PostgreSQL 14: " +
+			"detach the partition when the table is idle, with a short lock_timeout."
+	}
+	return "Use DETACH PARTITION ... CONCURRENTLY instead, outside a transaction."
+}
+
+func (r DetachPartitionNonConcurrently) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r DetachPartitionNonConcurrently) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	for _, cmd := range node.GetAlterTableStmt().GetCmds() {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		if alterTableCmd.GetSubtype() == pg_query.AlterTableType_AT_DetachPartition && !alterTableCmd.GetDef().GetPartitionCmd().GetConcurrent() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetachPartitionNonConcurrently_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "detach partition",
+			sql:  `ALTER TABLE measurements DETACH PARTITION measurements_2020;`,
+			want: true,
+		},
+		{
+			name: "detach partition concurrently",
+			sql:  `ALTER TABLE measurements DETACH PARTITION measurements_2020 CONCURRENTLY;`,
+			want: false,
+		},
+		{
+			name: "attach partition",
+			sql:  `ALTER TABLE measurements ATTACH PARTITION measurements_2020 FOR VALUES FROM ('2020-01-01') TO ('2021-01-01');`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			node := parseStatement(t, tt.sql)
+			assert.Equal(t, tt.want, DetachPartitionNonConcurrently{}.Process(node, MigrationContext{AllStatements: []*pg_query.Node{node}}))
+		})
+	}
+}
+
+func TestDetachPartitionNonConcurrently_Explain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version int
+		want    string
+	}{
+		{name: "unknown version", want: "Use DETACH PARTITION ... CONCURRENTLY instead, outside a transaction."},
+		{name: "PostgreSQL 14", version: 14, want: "Use DETACH PARTITION ... CONCURRENTLY instead, outside a transaction."},
+		{
+			name:    "PostgreSQL 13",
+			version: 13,
+			want:    "DETACH PARTITION CONCURRENTLY is not available before PostgreSQL 14: detach the partition when the table is idle, with a short lock_timeout.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			node := parseStatement(t, `ALTER TABLE measurements DETACH PARTITION measurements_2020;`)
+			assert.Equal(t, tt.want, DetachPartitionNonConcurrently{}.Explain(node, MigrationContext{PostgresVersion: tt.version}))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package rules
 import (
 	"errors"
 	"fmt"
+	mapset "github.com/deckarep/golang-set/v2"
 	pg_query "github.com/pganalyze/pg_query_go/v4"
 	"github.com/pganalyze/pg_query_go/v4/parser"
 	migrate "github.com/rubenv/sql-migrate"
@@ -18,10 +19,24 @@ type Rule interface {
 	// followed by a code that briefly explains the rule scope.
 	Alias() string
 	// Process receives a parsed SQL statement that is part of the migration,
-	// along with the entire set of migration statements and a flag denoting that
-	// the statement is executed within an active transaction or not.
+	// along with the migration context, which includes the entire set of migration statements,
+	// the migration direction and a flag denoting that the statement is executed within
+	// an active transaction or not.
 	// Returns true if the rule matches and a warning must be produced for this statement.
-	Process(node *pg_query.Node, allStatements []*pg_query.Node, inTransaction bool) bool
+	Process(node *pg_query.Node, ctx MigrationContext) bool
+}
+
+// Configurable is implemented by rules that accept user-defined settings.
+type Configurable interface {
+	// Configure receives a function that decodes the rule settings into the given value
+	// and returns a copy of the rule with the settings applied.
+	Configure(unmarshal func(any) error) (Rule, error)
+}
+
+// Explainer is implemented by rules that provide details about a violation,
+// specific to the statement and the migration context, e.g. the other statements involved.
+type Explainer interface {
+	Explain(node *pg_query.Node, ctx MigrationContext) string
 }
 
 type RuleSet map[string]Rule
@@ -59,6 +74,31 @@ func (r RuleSet) Except(aliases ...string) RuleSet {
 	return filtered
 }
 
+// Configure applies the settings to the rules matching each alias.
+// Returns an error if an alias is unknown or the rule does not accept settings.
+func (r RuleSet) Configure(settings map[string]func(any) error) (RuleSet, error) {
+	configured := NewRuleSet()
+	for alias, rule := range r {
+		configured[alias] = rule
+	}
+	for alias, unmarshal := range settings {
+		rule, ok := r[alias]
+		if !ok {
+			return nil, fmt.Errorf("unknown alias %q", alias)
+		}
+		configurable, ok := rule.(Configurable)
+		if !ok {
+			return nil, fmt.Errorf("rule %s does not accept settings", alias)
+		}
+		rule, err := configurable.Configure(unmarshal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid settings for rule %s: %w", alias, err)
+		}
+		configured[alias] = rule
+	}
+	return configured, nil
+}
+
 func (r RuleSet) Contains(alias string) bool {
 	_, ok := r[alias]
 	return ok
@@ -85,11 +125,16 @@ type ReportedError interface {
 	Alias() string
 	Documentation() string
 	Statement() string
+	// Details returns information specific to the reported statement. Can be empty.
+	Details() string
+	Severity() Severity
 }
 
 type Violation struct {
 	rule      Rule
 	statement string
+	details   string
+	severity  Severity
 }
 
 func (e Violation) Alias() string {
@@ -104,6 +149,14 @@ func (e Violation) Statement() string {
 	return e.statement
 }
 
+func (e Violation) Details() string {
+	return e.details
+}
+
+func (e Violation) Severity() Severity {
+	return e.severity
+}
+
 func (r RuleSet) ProcessAll(ctx MigrationContext, statements []string) ([]StatementResult, error) {
 	var results []StatementResult
 	type task struct {
@@ -137,6 +190,7 @@ func (r RuleSet) ProcessAll(ctx MigrationContext, statements []string) ([]Statem
 		}
 	}
 	ctx.AllStatements = allStatements
+	ctx.cache = &sectionCache{}
 	for _, task := range tasks {
 		ctx := ctx
 		ctx.RawSQL = task.rawSQL
@@ -151,9 +205,13 @@ func (r RuleSet) ProcessAll(ctx MigrationContext, statements []string) ([]Statem
 func (r RuleSet) processSingle(ctx MigrationContext, statement *pg_query.RawStmt) StatementResult {
 	result := StatementResult{Passed: true, Direction: ctx.Direction}
 	for _, rule := range r.SortedSlice() {
-		if rule.Process(statement.Stmt, ctx.AllStatements, ctx.InTransaction) {
+		if rule.Process(statement.Stmt, ctx) {
 			result.Passed = false
-			result.Errors = append(result.Errors, Violation{rule: rule, statement: ctx.RawSQL})
+			violation := Violation{rule: rule, statement: ctx.RawSQL, severity: severityOf(rule, statement.Stmt, ctx)}
+			if explainer, ok := rule.(Explainer); ok {
+				violation.details = explainer.Explain(statement.Stmt, ctx)
+			}
+			result.Errors = append(result.Errors, violation)
 		}
 	}
 	return result
@@ -167,7 +225,20 @@ func parseStatements(sql string) ([]*pg_query.RawStmt, error) {
 	return tree.GetStmts(), nil
 }
 
-var availableRules = NewRuleSet()
+// deparse returns the SQL representation of the statement.
+func deparse(node *pg_query.Node) string {
+	sql, err := pg_query.Deparse(&pg_query.ParseResult{Stmts: []*pg_query.RawStmt{{Stmt: node}}})
+	if err != nil {
+		return ""
+	}
+	return sql
+}
+
+var (
+	availableRules = NewRuleSet()
+	// optInRules contains the aliases of the rules that are disabled unless explicitly enabled.
+	optInRules = mapset.NewSet[string]()
+)
 
 func All() RuleSet {
 	set := make(RuleSet, len(availableRules))
@@ -178,17 +249,78 @@ func All() RuleSet {
 	return set
 }
 
+// IsOptIn returns true if the rule with the given alias is disabled unless explicitly enabled.
+func IsOptIn(alias string) bool {
+	return optInRules.Contains(alias)
+}
+
+// Enabled returns the rules that are enabled by default, along with the given opt-in rules.
+func (r RuleSet) Enabled(optIn ...string) RuleSet {
+	enabled := mapset.NewSet[string](optIn...)
+	filtered := NewRuleSet()
+	for alias, rule := range r {
+		rule := rule
+		if !IsOptIn(alias) || enabled.Contains(alias) {
+			filtered.Add(rule)
+		}
+	}
+	return filtered
+}
+
+func addOptIn(rule Rule) {
+	availableRules.Add(rule)
+	optInRules.Add(rule.Alias())
+}
+
 func init() {
+	availableRules.Add(Cluster{})
 	availableRules.Add(ColumnComment{})
 	availableRules.Add(ColumnSetNotNull{})
+	availableRules.Add(ColumnTypeRewrite{})
+	availableRules.Add(ConcurrentRefreshRequiresUniqueIndex{})
 	availableRules.Add(CreateIndexNonConcurrently{})
+	availableRules.Add(DDLAndDMLMix{})
+	availableRules.Add(DetachPartitionNonConcurrently{})
+	availableRules.Add(DownDropsUnrelatedObject{})
+	availableRules.Add(DropCascade{})
+	availableRules.Add(DropColumn{})
 	availableRules.Add(DropIndexNonConcurrently{})
+	availableRules.Add(DropTable{})
+	availableRules.Add(EnumAddValueInTransaction{})
+	availableRules.Add(EnumNewValueUsedInTransaction{})
+	availableRules.Add(ForeignKeyRequiresIndex{})
+	availableRules.Add(IdentifierTooLong{})
 	availableRules.Add(IndexMustBeNamed{})
 	availableRules.Add(IndexOperationNotIdempotent{})
+	availableRules.Add(IrreversibleMigration{})
+	availableRules.Add(LockTimeoutRequired{})
+	availableRules.Add(NamingConvention{})
 	availableRules.Add(NestedTransaction{})
+	availableRules.Add(RefreshMaterializedViewNonConcurrently{})
+	availableRules.Add(ReindexNonConcurrently{})
+	availableRules.Add(RenameColumn{})
+	availableRules.Add(RenameSchema{})
+	availableRules.Add(RenameSequence{})
 	availableRules.Add(RenameTable{})
+	availableRules.Add(RenameView{})
 	availableRules.Add(RequiredColumn{})
+	availableRules.Add(TableRequiresPrimaryKey{})
+	availableRules.Add(TransactionLocks{MaxAccessExclusiveRelations: DefaultMaxAccessExclusiveRelations})
 	availableRules.Add(TransactionNotSupportedInConcurrentIndexOperations{})
+	availableRules.Add(Truncate{})
+	availableRules.Add(UnfilteredUpdateOrDelete{})
+	availableRules.Add(UpChangesNotReverted{})
+	availableRules.Add(VacuumFull{})
+	availableRules.Add(VacuumInTransaction{})
+	availableRules.Add(VolatileColumnDefault{StableFunctions: DefaultStableFunctions})
+
+	addOptIn(AvoidChar{})
+	addOptIn(AvoidMoney{})
+	addOptIn(PreferBigintPrimaryKey{})
+	addOptIn(PreferJSONB{})
+	addOptIn(PreferText{})
+	addOptIn(PreferTimestamptz{})
+	addOptIn(StatementTimeoutRequired{})
 }
 
 type Category string
@@ -197,12 +329,20 @@ const (
 	CategoryHighAvailability Category = "high-availability"
 	CategoryTransactions     Category = "transactions"
 	CategoryMaintainability  Category = "maintainability"
+	CategoryDataSafety       Category = "data-safety"
+	CategoryPerformance      Category = "performance"
+	CategoryConventions      Category = "conventions"
+	CategoryConsistency      Category = "consistency"
 )
 
 func Categories() []Category {
 	return []Category{
+		CategoryConsistency,
+		CategoryConventions,
+		CategoryDataSafety,
 		CategoryHighAvailability,
 		CategoryMaintainability,
+		CategoryPerformance,
 		CategoryTransactions,
 	}
 }
@@ -219,6 +359,22 @@ func MaintainabilityRule(code string) string {
 	return fmt.Sprintf("%s-%s", CategoryMaintainability, code)
 }
 
+func DataSafetyRule(code string) string {
+	return fmt.Sprintf("%s-%s", CategoryDataSafety, code)
+}
+
+func PerformanceRule(code string) string {
+	return fmt.Sprintf("%s-%s", CategoryPerformance, code)
+}
+
+func ConventionsRule(code string) string {
+	return fmt.Sprintf("%s-%s", CategoryConventions, code)
+}
+
+func ConsistencyRule(code string) string {
+	return fmt.Sprintf("%s-%s", CategoryConsistency, code)
+}
+
 func CategoryFromAlias(alias string) string {
 	for _, c := range Categories() {
 		if strings.HasPrefix(alias, string(c)+"-") {
@@ -244,3 +400,11 @@ func (e ParseError) Documentation() string {
 func (e ParseError) Statement() string {
 	return e.statement
 }
+
+func (e ParseError) Details() string {
+	return ""
+}
+
+func (e ParseError) Severity() Severity {
+	return SeverityError
+}
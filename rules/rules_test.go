@@ -1,8 +1,10 @@
 package rules
 
 import (
+	"errors"
 	pg_query "github.com/pganalyze/pg_query_go/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
@@ -13,8 +15,8 @@ type mockRule struct {
 func (m mockRule) Alias() string {
 	return m.alias
 }
-func (m mockRule) Documentation() string                                     { return "" }
-func (m mockRule) Process(_ *pg_query.Node, _ []*pg_query.Node, _ bool) bool { return false }
+func (m mockRule) Documentation() string                             { return "" }
+func (m mockRule) Process(_ *pg_query.Node, _ MigrationContext) bool { return false }
 
 func TestRuleSet_Contains(t *testing.T) {
 	t.Parallel()
@@ -142,3 +144,72 @@ func TestRuleSet_Except(t *testing.T) {
 		})
 	}
 }
+
+type configurableMockRule struct {
+	mockRule
+	Setting string
+}
+
+func (m configurableMockRule) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&m.Setting); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func TestRuleSet_Configure(t *testing.T) {
+	t.Parallel()
+
+	set := NewRuleSet()
+	set.Add(mockRule{alias: "test-A"})
+	set.Add(configurableMockRule{mockRule: mockRule{alias: "test-B"}})
+
+	t.Run("applies the settings to configurable rules", func(t *testing.T) {
+		t.Parallel()
+
+		configured, err := set.Configure(map[string]func(any) error{
+			"test-B": func(v any) error {
+				*(v.(*string)) = "configured"
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, mockRule{alias: "test-A"}, configured["test-A"])
+		assert.Equal(t, configurableMockRule{mockRule: mockRule{alias: "test-B"}, Setting: "configured"}, configured["test-B"])
+		assert.Equal(t, configurableMockRule{mockRule: mockRule{alias: "test-B"}}, set["test-B"])
+	})
+
+	t.Run("unknown alias", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := set.Configure(map[string]func(any) error{"test-Z": func(any) error { return nil }})
+		assert.Error(t, err)
+	})
+
+	t.Run("rule does not accept settings", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := set.Configure(map[string]func(any) error{"test-A": func(any) error { return nil }})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := set.Configure(map[string]func(any) error{"test-B": func(any) error { return errors.New("invalid") }})
+		assert.Error(t, err)
+	})
+}
+
+func TestRuleSet_Enabled(t *testing.T) {
+	t.Parallel()
+
+	optIn := StatementTimeoutRequired{}.Alias()
+	require.True(t, IsOptIn(optIn))
+	require.False(t, IsOptIn(NestedTransaction{}.Alias()))
+
+	assert.False(t, All().Enabled().Contains(optIn))
+	assert.True(t, All().Enabled().Contains(NestedTransaction{}.Alias()))
+	assert.True(t, All().Enabled(optIn).Contains(optIn))
+	assert.Len(t, All().Enabled(optIn), len(All().Enabled())+1)
+}
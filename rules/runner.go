@@ -1,21 +1,154 @@
 package rules
 
 import (
+	"sync"
+
 	pg_query "github.com/pganalyze/pg_query_go/v4"
 	migrate "github.com/rubenv/sql-migrate"
 	"pgsafemigrate/annotations"
+	"pgsafemigrate/catalog"
+	"pgsafemigrate/hints"
 	"pgsafemigrate/loader"
+	"pgsafemigrate/locks"
 )
 
+// MigrationContext contains the information about the migration section being processed,
+// shared by all the statements of the section.
 type MigrationContext struct {
 	AllStatements []*pg_query.Node
+	// Catalog contains the schema before the statements of the section are executed: the schema built from the forward
+	// migrations applied before the current one, along with the forward statements of the current one for the rollback.
+	// It must not be modified by rules.
+	Catalog       *catalog.Catalog
 	Direction     migrate.MigrationDirection
 	FilePath      string
 	InTransaction bool
-	RawSQL        string
+	// PostgresVersion is the target PostgreSQL major version, zero when unknown.
+	PostgresVersion int
+	RawSQL          string
+	// TableHints contains the approximate size and traffic of the tables.
+	TableHints hints.Hints
+	// LockHistory contains the lock order of the forward migrations applied before the current one. Can be nil.
+	LockHistory *locks.History
+	// Annotated is set for migrations that define their directions with sql-migrate annotations.
+	Annotated bool
+	// OppositeStatements contains the statements of the other direction of the migration,
+	// i.e. the rollback statements when processing the forward migration and vice versa.
+	OppositeStatements []*pg_query.Node
+	// OppositeCatalog contains the schema before the opposite statements are executed. Can be nil.
+	OppositeCatalog *catalog.Catalog
+
+	// cache is shared by the copies of the context made for the statements of the section. Can be nil.
+	cache *sectionCache
+}
+
+// sectionCache contains the data derived from all the statements of a migration section.
+// Each part is computed on first use, so that rules do not derive it again for every statement of the section.
+type sectionCache struct {
+	positionsOnce sync.Once
+	positions     map[*pg_query.Node]int
+	// createdRelations contains the position of the first statement that creates each table, view or materialized view.
+	createdRelations map[string]int
+
+	timelineOnce sync.Once
+	transactions []locks.Transaction
+	held         [][]locks.HeldLock
+	// timelinePositions contains the transaction of each statement and the index of the statement in the transaction.
+	timelinePositions map[*pg_query.Node][2]int
+
+	consistencyOnce sync.Once
+	// created contains the objects created by each statement of a forward migration.
+	created map[*pg_query.Node][]schemaObject
+	// oppositeCreated and oppositeDropped contain the objects created by the forward migration of a rollback,
+	// and dropped by the rollback of a forward migration.
+	oppositeCreated []schemaObject
+	oppositeDropped []schemaObject
+}
+
+// sections returns the cache of the section, or an empty one when the context is not shared.
+func (c MigrationContext) sections() *sectionCache {
+	if c.cache == nil {
+		return &sectionCache{}
+	}
+	return c.cache
+}
+
+// indexed returns the cache of the section, with the positions of the statements and the relations they create.
+func (c MigrationContext) indexed() *sectionCache {
+	cache := c.sections()
+	cache.positionsOnce.Do(func() {
+		cache.positions = make(map[*pg_query.Node]int, len(c.AllStatements))
+		cache.createdRelations = make(map[string]int)
+		for i, n := range c.AllStatements {
+			cache.positions[n] = i
+			for _, relation := range []string{
+				n.GetCreateStmt().GetRelation().GetRelname(),
+				n.GetCreateTableAsStmt().GetInto().GetRel().GetRelname(),
+				n.GetViewStmt().GetView().GetRelname(),
+			} {
+				if _, ok := cache.createdRelations[relation]; !ok && relation != "" {
+					cache.createdRelations[relation] = i
+				}
+			}
+		}
+	})
+	return cache
+}
+
+// PrecedingStatements returns the statements of the migration section that are executed before the given statement.
+func (c MigrationContext) PrecedingStatements(node *pg_query.Node) []*pg_query.Node {
+	if i, ok := c.indexed().positions[node]; ok {
+		return c.AllStatements[:i]
+	}
+	return nil
+}
+
+// CreatedInMigration reports whether the table, view or materialized view is created
+// by a statement of the migration section that is executed before the given statement.
+func (c MigrationContext) CreatedInMigration(relation string, node *pg_query.Node) bool {
+	cache := c.indexed()
+	i, ok := cache.positions[node]
+	created, createdInSection := cache.createdRelations[relation]
+	return ok && createdInSection && created < i
 }
 
-func ProcessMigration(migrationFile loader.MigrationFile, excludedRules []string) ([]StatementResult, error) {
+// CatalogBefore returns the schema as it is before the given statement is executed,
+// i.e. the migration catalog with the preceding statements of the migration section applied.
+func (c MigrationContext) CatalogBefore(node *pg_query.Node) *catalog.Catalog {
+	return c.catalogWith(c.PrecedingStatements(node))
+}
+
+// CatalogAfter returns the schema as it is after all the statements of the migration section are executed.
+func (c MigrationContext) CatalogAfter() *catalog.Catalog {
+	return c.catalogWith(c.AllStatements)
+}
+
+func (c MigrationContext) catalogWith(statements []*pg_query.Node) *catalog.Catalog {
+	cat := catalog.New()
+	if c.Catalog != nil {
+		cat = c.Catalog.Clone()
+	}
+	for _, n := range statements {
+		cat.Apply(n)
+	}
+	return cat
+}
+
+// Target describes the database that the migrations are applied to.
+type Target struct {
+	// Catalog contains the schema built from the migrations applied before the processed migration. It is not modified.
+	Catalog *catalog.Catalog
+	// PostgresVersion is the PostgreSQL major version, zero when unknown.
+	PostgresVersion int
+	// TableHints contains the approximate size and traffic of the tables.
+	TableHints hints.Hints
+	// LockHistory contains the lock order of the migrations applied before the processed migration. Can be nil.
+	LockHistory *locks.History
+}
+
+// ProcessMigration evaluates the rules against the statements of both migration directions.
+// Rules excluded with no-lint annotations are removed from the given rule set for the respective direction.
+func ProcessMigration(migrationFile loader.MigrationFile, ruleSet RuleSet, target Target) ([]StatementResult, error) {
 	migration, err := loader.LoadMigration(migrationFile.Contents)
 	if err != nil {
 		return nil, err
@@ -26,23 +159,49 @@ func ProcessMigration(migrationFile loader.MigrationFile, excludedRules []string
 		panic(err)
 	}
 
-	var results []StatementResult
-	upRules := All().Except(append(excludedRules, nl[migrate.Up].RuleNames...)...)
+	var (
+		results                []StatementResult
+		annotated              = loader.HasDirectionAnnotations(migrationFile.Contents)
+		upStatements, upOK     = parseAll(migration.UpStatements)
+		downStatements, downOK = parseAll(migration.DownStatements)
+	)
+	upRules := ruleSet.Except(nl[migrate.Up].RuleNames...)
+	downRules := ruleSet.Except(nl[migrate.Down].RuleNames...)
+	// The consistency rules cannot correlate the directions when either of them has statements that cannot be parsed
+	if !upOK || !downOK {
+		upRules = upRules.Except(categoryAliases(CategoryConsistency)...)
+		downRules = downRules.Except(categoryAliases(CategoryConsistency)...)
+	}
+	// The rollback is executed after the forward migration
+	rollbackCatalog := MigrationContext{Catalog: target.Catalog, AllStatements: upStatements}.CatalogAfter()
 	upResults, err := upRules.ProcessAll(MigrationContext{
-		InTransaction: !migration.DisableTransactionUp,
-		Direction:     migrate.Up,
-		FilePath:      migrationFile.Path,
+		InTransaction:      !migration.DisableTransactionUp,
+		Direction:          migrate.Up,
+		FilePath:           migrationFile.Path,
+		Catalog:            target.Catalog,
+		PostgresVersion:    target.PostgresVersion,
+		TableHints:         target.TableHints,
+		LockHistory:        target.LockHistory,
+		Annotated:          annotated,
+		OppositeStatements: downStatements,
+		OppositeCatalog:    rollbackCatalog,
 	}, migration.UpStatements)
 	if err != nil {
 		return nil, err
 	}
 	results = append(results, upResults...)
 
-	downRules := All().Except(append(excludedRules, nl[migrate.Down].RuleNames...)...)
 	downResults, err := downRules.ProcessAll(MigrationContext{
-		InTransaction: !migration.DisableTransactionDown,
-		Direction:     migrate.Down,
-		FilePath:      migrationFile.Path,
+		InTransaction:      !migration.DisableTransactionDown,
+		Direction:          migrate.Down,
+		FilePath:           migrationFile.Path,
+		Catalog:            rollbackCatalog,
+		PostgresVersion:    target.PostgresVersion,
+		TableHints:         target.TableHints,
+		LockHistory:        target.LockHistory,
+		Annotated:          annotated,
+		OppositeStatements: upStatements,
+		OppositeCatalog:    target.Catalog,
 	}, migration.DownStatements)
 	if err != nil {
 		return nil, err
@@ -52,6 +211,48 @@ func ProcessMigration(migrationFile loader.MigrationFile, excludedRules []string
 	return results, nil
 }
 
+// UpStatements returns the parsed forward migration statements of the migration file.
+// Statements that cannot be parsed are skipped.
+func UpStatements(migrationFile loader.MigrationFile) ([]*pg_query.Node, error) {
+	migration, err := loader.LoadMigration(migrationFile.Contents)
+	if err != nil {
+		return nil, err
+	}
+	statements, _ := parseAll(migration.UpStatements)
+	return statements, nil
+}
+
+// parseAll returns the parsed statements of the given SQL statements, and whether all of them could be parsed.
+// Statements that cannot be parsed are skipped.
+func parseAll(sqlStatements []string) ([]*pg_query.Node, bool) {
+	var (
+		statements []*pg_query.Node
+		ok         = true
+	)
+	for _, sql := range sqlStatements {
+		stmts, err := parseStatements(sql)
+		if err != nil {
+			ok = false
+			continue
+		}
+		for _, s := range stmts {
+			statements = append(statements, s.Stmt)
+		}
+	}
+	return statements, ok
+}
+
+// categoryAliases returns the aliases of all the available rules of the category.
+func categoryAliases(category Category) []string {
+	var aliases []string
+	for alias := range All() {
+		if CategoryFromAlias(alias) == string(category) {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
 func noLint(migrationFileContents string) (map[migrate.MigrationDirection]annotations.NoLint, error) {
 	comments, err := loader.ScanCommentsFromString(migrationFileContents)
 	if err != nil {
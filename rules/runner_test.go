@@ -1,8 +1,10 @@
 package rules
 
 import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
 	migrate "github.com/rubenv/sql-migrate"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"pgsafemigrate/loader"
 	"testing"
 )
@@ -23,7 +25,8 @@ func TestProcessMigration(t *testing.T) {
 			args: args{
 				migrationFile: loader.MigrationFile{
 					Path: "test1.sql",
-					Contents: `ALTER TABLE movies ADD COLUMN released_at TIMESTAMP;
+					Contents: `SET lock_timeout = '5s';
+ALTER TABLE movies ADD COLUMN released_at TIMESTAMP;
 COMMENT ON COLUMN movies.released_at IS 'First release date';`,
 				},
 			},
@@ -36,6 +39,10 @@ COMMENT ON COLUMN movies.released_at IS 'First release date';`,
 					Passed:    true,
 					Direction: migrate.Up,
 				},
+				{
+					Passed:    true,
+					Direction: migrate.Up,
+				},
 			},
 			wantErr: assert.NoError,
 		},
@@ -46,10 +53,12 @@ COMMENT ON COLUMN movies.released_at IS 'First release date';`,
 					Path: "test1.sql",
 					Contents: `
 -- +migrate Up
+SET lock_timeout = '5s';
 ALTER TABLE movies ADD COLUMN released_at TIMESTAMP;
 COMMENT ON COLUMN movies.released_at IS 'First release date';
 
 -- +migrate Down
+SET lock_timeout = '5s';
 ALTER TABLE movies DROP COLUMN released_at;
 `,
 				},
@@ -63,6 +72,14 @@ ALTER TABLE movies DROP COLUMN released_at;
 					Passed:    true,
 					Direction: migrate.Up,
 				},
+				{
+					Passed:    true,
+					Direction: migrate.Up,
+				},
+				{
+					Passed:    true,
+					Direction: migrate.Down,
+				},
 				{
 					Passed:    true,
 					Direction: migrate.Down,
@@ -81,6 +98,7 @@ ALTER TABLE movies ADD COLUMN TIMESTAMP;
 COMMENT ON COLUMN movies.released_at IS 'First release date';
 
 -- +migrate Down
+SET lock_timeout = '5s';
 ALTER TABLE movies DROP COLUMN released_at;
 `,
 				},
@@ -99,6 +117,10 @@ ALTER TABLE movies DROP COLUMN released_at;
 					Passed:    true,
 					Direction: migrate.Down,
 				},
+				{
+					Passed:    true,
+					Direction: migrate.Down,
+				},
 			},
 			wantErr: assert.NoError,
 		},
@@ -108,11 +130,16 @@ ALTER TABLE movies DROP COLUMN released_at;
 				migrationFile: loader.MigrationFile{
 					Path: "test1.sql",
 					Contents: `
+SET lock_timeout = '5s';
 CREATE INDEX test_idx ON movies(title);
 `,
 				},
 			},
 			want: []StatementResult{
+				{
+					Passed:    true,
+					Direction: migrate.Up,
+				},
 				{
 					Passed:    false,
 					Direction: migrate.Up,
@@ -132,6 +159,7 @@ CREATE INDEX test_idx ON movies(title);
 				migrationFile: loader.MigrationFile{
 					Path: "test1.sql",
 					Contents: `-- pgsafemigrate:nolint:high-availability-avoid-non-concurrent-index-creation
+SET lock_timeout = '5s';
 CREATE INDEX test_idx ON movies(title);
 `,
 				},
@@ -141,6 +169,10 @@ CREATE INDEX test_idx ON movies(title);
 					Passed:    true,
 					Direction: migrate.Up,
 				},
+				{
+					Passed:    true,
+					Direction: migrate.Up,
+				},
 			},
 			wantErr: assert.NoError,
 		},
@@ -150,11 +182,16 @@ CREATE INDEX test_idx ON movies(title);
 				migrationFile: loader.MigrationFile{
 					Path: "test1.sql",
 					Contents: `-- pgsafemigrate:nolint:high-availability-another-rule
+SET lock_timeout = '5s';
 CREATE INDEX test_idx ON movies(title);
 `,
 				},
 			},
 			want: []StatementResult{
+				{
+					Passed:    true,
+					Direction: migrate.Up,
+				},
 				{
 					Passed:    false,
 					Direction: migrate.Up,
@@ -173,9 +210,91 @@ CREATE INDEX test_idx ON movies(title);
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := ProcessMigration(tt.args.migrationFile, tt.args.excludedRules)
+			got, err := ProcessMigration(tt.args.migrationFile, All().Enabled().Except(tt.args.excludedRules...), Target{})
 			tt.wantErr(t, err)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func TestProcessMigration_RollbackCatalog(t *testing.T) {
+	t.Parallel()
+
+	ruleSet := NewRuleSet()
+	ruleSet.Add(DropCascade{})
+	got, err := ProcessMigration(loader.MigrationFile{
+		Path: "test1.sql",
+		Contents: `
+-- +migrate Up
+CREATE TABLE parent (id bigint PRIMARY KEY);
+CREATE VIEW parent_ids AS SELECT id FROM parent;
+
+-- +migrate Down
+DROP TABLE parent CASCADE;`,
+	}, ruleSet, Target{})
+	require.NoError(t, err)
+
+	var details []string
+	for _, result := range got {
+		if result.Direction != migrate.Down {
+			continue
+		}
+		for _, reported := range result.Errors {
+			details = append(details, reported.(Violation).Details())
+		}
+	}
+	assert.Equal(t, []string{"Known dependent objects: view parent_ids"}, details)
+}
+
+func TestMigrationContext_PrecedingStatements(t *testing.T) {
+	t.Parallel()
+
+	first := parseStatement(t, `SET lock_timeout = '5s';`)
+	second := parseStatement(t, `ALTER TABLE movies ADD COLUMN released_at TIMESTAMP;`)
+	third := parseStatement(t, `COMMENT ON COLUMN movies.released_at IS 'First release date';`)
+	ctx := MigrationContext{AllStatements: []*pg_query.Node{first, second, third}}
+
+	assert.Empty(t, ctx.PrecedingStatements(first))
+	assert.Equal(t, []*pg_query.Node{first, second}, ctx.PrecedingStatements(third))
+	assert.Nil(t, ctx.PrecedingStatements(parseStatement(t, `SELECT 1;`)))
+}
+
+func TestMigrationContext_Catalog(t *testing.T) {
+	t.Parallel()
+
+	first := parseStatement(t, `ALTER TABLE movies ADD COLUMN released_at timestamptz;`)
+	second := parseStatement(t, `CREATE INDEX movies_released_at_idx ON movies (released_at);`)
+	cat := newCatalog([]*pg_query.Node{parseStatement(t, `CREATE TABLE movies (id bigint PRIMARY KEY);`)})
+	ctx := MigrationContext{AllStatements: []*pg_query.Node{first, second}, Catalog: cat}
+
+	movies, ok := ctx.CatalogBefore(first).Table("movies")
+	require.True(t, ok)
+	assert.Len(t, movies.Columns, 1)
+	movies, _ = ctx.CatalogBefore(second).Table("movies")
+	assert.Len(t, movies.Columns, 2)
+	assert.Len(t, ctx.CatalogBefore(second).Indexes("movies"), 1)
+	assert.Len(t, ctx.CatalogAfter().Indexes("movies"), 2)
+
+	// The migration catalog is not modified
+	movies, _ = cat.Table("movies")
+	assert.Len(t, movies.Columns, 1)
+}
+
+func TestUpStatements(t *testing.T) {
+	t.Parallel()
+
+	statements, err := UpStatements(loader.MigrationFile{
+		Path: "test1.sql",
+		Contents: `
+-- +migrate Up
+CREATE TABLE movies (id bigint PRIMARY KEY);
+ALTER TABLE movies ADD COLUMN TIMESTAMP;
+
+-- +migrate Down
+DROP TABLE movies;
+`,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, statements, 1)
+	assert.NotNil(t, statements[0].GetCreateStmt())
+}
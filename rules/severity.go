@@ -0,0 +1,54 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/hints"
+)
+
+// Severity is the severity of a reported violation. The zero value is SeverityWarning.
+type Severity int
+
+const (
+	// SeverityInfo is reported without failing the check.
+	SeverityInfo Severity = iota - 1
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityError:
+		return "error"
+	}
+	return "warning"
+}
+
+// Fails reports whether violations of this severity fail the check.
+func (s Severity) Fails() bool {
+	return s >= SeverityWarning
+}
+
+// lockRule is implemented by the rules that report statements because of the locks they hold,
+// whose impact depends on the size and traffic of the locked tables. It is implemented by embedding NewTableExemption.
+type lockRule interface {
+	reportsLocks()
+}
+
+// severityOf returns the severity of a violation of the rule.
+// Violations of the rules about locks are downgraded to info when all the tables locked by the statement are small,
+// and escalated to error when any of them is large or hot, according to the table hints.
+// Other violations, e.g. renaming a column, break the running application whatever the table size.
+func severityOf(rule Rule, node *pg_query.Node, ctx MigrationContext) Severity {
+	if _, ok := rule.(lockRule); !ok {
+		return SeverityWarning
+	}
+	switch ctx.TableHints.Size(lockedRelations(node, ctx)...) {
+	case hints.Small:
+		return SeverityInfo
+	case hints.Large:
+		return SeverityError
+	}
+	return SeverityWarning
+}
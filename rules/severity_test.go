@@ -0,0 +1,98 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"pgsafemigrate/hints"
+	"testing"
+)
+
+func TestSeverityOf(t *testing.T) {
+	t.Parallel()
+
+	tableHints := hints.Hints{Tables: map[string]hints.Table{
+		"countries": {Rows: 250},
+		"events":    {Rows: 500_000_000},
+		"sessions":  {Rows: 1_000, Hot: true},
+	}}
+	tests := []struct {
+		name string
+		rule Rule
+		sql  string
+		want Severity
+	}{
+		{
+			name: "table without hints",
+			rule: CreateIndexNonConcurrently{},
+			sql:  `CREATE INDEX movies_title_idx ON movies (title);`,
+			want: SeverityWarning,
+		},
+		{
+			name: "small table",
+			rule: CreateIndexNonConcurrently{},
+			sql:  `CREATE INDEX countries_name_idx ON countries (name);`,
+			want: SeverityInfo,
+		},
+		{
+			name: "large table",
+			rule: CreateIndexNonConcurrently{},
+			sql:  `CREATE INDEX events_created_at_idx ON events (created_at);`,
+			want: SeverityError,
+		},
+		{
+			name: "hot table",
+			rule: RequiredColumn{},
+			sql:  `ALTER TABLE sessions ADD COLUMN user_id bigint NOT NULL;`,
+			want: SeverityError,
+		},
+		{
+			name: "small and large tables",
+			rule: LockTimeoutRequired{},
+			sql:  `TRUNCATE countries, events;`,
+			want: SeverityError,
+		},
+		{
+			name: "small table referencing a large table",
+			rule: LockTimeoutRequired{},
+			sql:  `ALTER TABLE countries ADD CONSTRAINT countries_event_id_fkey FOREIGN KEY (event_id) REFERENCES events (id);`,
+			want: SeverityError,
+		},
+		{
+			name: "rule about application compatibility on a small table",
+			rule: DropTable{},
+			sql:  `DROP TABLE countries;`,
+			want: SeverityWarning,
+		},
+		{
+			name: "rule about application compatibility on a large table",
+			rule: RenameColumn{},
+			sql:  `ALTER TABLE events RENAME COLUMN name TO title;`,
+			want: SeverityWarning,
+		},
+		{
+			name: "rule of another category",
+			rule: IndexMustBeNamed{},
+			sql:  `CREATE INDEX ON countries (name);`,
+			want: SeverityWarning,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			node := parseStatement(t, tt.sql)
+			ctx := MigrationContext{AllStatements: []*pg_query.Node{node}, TableHints: tableHints}
+			assert.Equal(t, tt.want, severityOf(tt.rule, node, ctx))
+		})
+	}
+}
+
+func TestSeverity_Fails(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, SeverityInfo.Fails())
+	assert.True(t, SeverityWarning.Fails())
+	assert.True(t, SeverityError.Fails())
+	assert.Equal(t, "warning", Severity(0).String())
+}
@@ -6,6 +6,7 @@ import (
 
 	mapset "github.com/deckarep/golang-set/v2"
 	pg_query "github.com/pganalyze/pg_query_go/v4"
+	migrate "github.com/rubenv/sql-migrate"
 )
 
 // RenameTable - Renaming a table can cause downtime to the previous application service version.
@@ -17,16 +18,136 @@ func (r RenameTable) Alias() string {
 func (r RenameTable) Documentation() string {
 	return "Renaming a table can cause errors in previous application versions."
 }
-func (r RenameTable) Process(node *pg_query.Node, _ []*pg_query.Node, _ bool) bool {
-	renameTableStmt := node.GetRenameStmt()
-	if renameTableStmt == nil {
+func (r RenameTable) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return isRenameOf(node, pg_query.ObjectType_OBJECT_TABLE)
+}
+
+// RenameColumn - Renaming a column can cause downtime to the previous application service version.
+type RenameColumn struct{}
+
+func (r RenameColumn) Alias() string {
+	return HighAvailabilityRule("avoid-column-rename")
+}
+
+func (r RenameColumn) Documentation() string {
+	return "Renaming a column can cause errors in previous application versions."
+}
+
+func (r RenameColumn) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return isRenameOf(node, pg_query.ObjectType_OBJECT_COLUMN)
+}
+
+// RenameView - Renaming a view can cause downtime to the previous application service version.
+type RenameView struct{}
+
+func (r RenameView) Alias() string {
+	return HighAvailabilityRule("avoid-view-rename")
+}
+
+func (r RenameView) Documentation() string {
+	return "Renaming a view or materialized view can cause errors in previous application versions."
+}
+
+func (r RenameView) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return isRenameOf(node, pg_query.ObjectType_OBJECT_VIEW, pg_query.ObjectType_OBJECT_MATVIEW)
+}
+
+// RenameSequence - Renaming a sequence can cause downtime to the previous application service version.
+type RenameSequence struct{}
+
+func (r RenameSequence) Alias() string {
+	return HighAvailabilityRule("avoid-sequence-rename")
+}
+
+func (r RenameSequence) Documentation() string {
+	return "Renaming a sequence can cause errors in previous application versions that reference it, e.g. with nextval()."
+}
+
+func (r RenameSequence) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return isRenameOf(node, pg_query.ObjectType_OBJECT_SEQUENCE)
+}
+
+// RenameSchema - Renaming a schema can cause downtime to the previous application service version.
+type RenameSchema struct{}
+
+func (r RenameSchema) Alias() string {
+	return HighAvailabilityRule("avoid-schema-rename")
+}
+
+func (r RenameSchema) Documentation() string {
+	return "Renaming a schema can cause errors in previous application versions that reference objects with schema-qualified names or rely on the search path."
+}
+
+func (r RenameSchema) Process(node *pg_query.Node, _ MigrationContext) bool {
+	return isRenameOf(node, pg_query.ObjectType_OBJECT_SCHEMA)
+}
+
+func isRenameOf(node *pg_query.Node, objectTypes ...pg_query.ObjectType) bool {
+	renameStmt := node.GetRenameStmt()
+	if renameStmt == nil {
+		return false
+	}
+	for _, t := range objectTypes {
+		if renameStmt.RenameType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// DropTable - Dropping a table in a forward migration can cause downtime to the previous application service version.
+type DropTable struct{}
+
+func (r DropTable) Alias() string {
+	return HighAvailabilityRule("avoid-table-drop")
+}
+
+func (r DropTable) Documentation() string {
+	return "Dropping a table in a forward migration can cause errors in previous application versions that are still running during deployment."
+}
+
+func (r DropTable) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if ctx.Direction != migrate.Up {
+		return false
+	}
+	dropStmt := node.GetDropStmt()
+	if dropStmt == nil {
 		return false
 	}
-	return renameTableStmt.RenameType == pg_query.ObjectType_OBJECT_TABLE
+	return dropStmt.RemoveType == pg_query.ObjectType_OBJECT_TABLE
+}
+
+// DropColumn - Dropping a column in a forward migration can cause downtime to the previous application service version.
+type DropColumn struct{}
+
+func (r DropColumn) Alias() string {
+	return HighAvailabilityRule("avoid-column-drop")
+}
+
+func (r DropColumn) Documentation() string {
+	return "Dropping a column in a forward migration can cause errors in previous application versions that are still running during deployment."
+}
+
+func (r DropColumn) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if ctx.Direction != migrate.Up {
+		return false
+	}
+	alterTable := node.GetAlterTableStmt()
+	if alterTable == nil {
+		return false
+	}
+	for _, cmd := range alterTable.GetCmds() {
+		if cmd.GetAlterTableCmd().GetSubtype() == pg_query.AlterTableType_AT_DropColumn {
+			return true
+		}
+	}
+	return false
 }
 
 // RequiredColumn - Adding a non-nullable column without a default value, makes the column required
-type RequiredColumn struct{}
+type RequiredColumn struct {
+	NewTableExemption `yaml:",inline"`
+}
 
 func (r RequiredColumn) Alias() string {
 	return HighAvailabilityRule("avoid-required-column")
@@ -35,7 +156,17 @@ func (r RequiredColumn) Documentation() string {
 	return "Newly added columns must either define a default value or be nullable."
 }
 
-func (r RequiredColumn) Process(node *pg_query.Node, _ []*pg_query.Node, _ bool) bool {
+func (r RequiredColumn) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r RequiredColumn) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
 	alterTable := node.GetAlterTableStmt()
 	if alterTable == nil {
 		return false
@@ -70,6 +201,60 @@ func (r RequiredColumn) Process(node *pg_query.Node, _ []*pg_query.Node, _ bool)
 	return false
 }
 
+// TableRequiresPrimaryKey - Tables without a primary key cannot be replicated with logical replication.
+type TableRequiresPrimaryKey struct{}
+
+func (r TableRequiresPrimaryKey) Alias() string {
+	return MaintainabilityRule("table-requires-primary-key")
+}
+
+// https://www.postgresql.org/docs/current/logical-replication-publication.html
+func (r TableRequiresPrimaryKey) Documentation() string {
+	return "Tables should define a PRIMARY KEY. Logical replication, which is commonly used for major-version upgrades, " +
+		"cannot replicate UPDATE and DELETE operations on tables without a replica identity."
+}
+
+func (r TableRequiresPrimaryKey) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	createStmt := node.GetCreateStmt()
+	if createStmt == nil {
+		return false
+	}
+	// Temporary tables are not replicated and partitions use the primary key of the partitioned table
+	if createStmt.GetRelation().GetRelpersistence() == "t" || createStmt.GetPartbound() != nil {
+		return false
+	}
+	for _, elt := range createStmt.GetTableElts() {
+		// Table definitions copied with LIKE can include the primary key
+		if elt.GetTableLikeClause() != nil {
+			return false
+		}
+		if elt.GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_PRIMARY {
+			return false
+		}
+		for _, constraint := range elt.GetColumnDef().GetConstraints() {
+			if constraint.GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_PRIMARY {
+				return false
+			}
+		}
+	}
+	// The primary key can be added in a separate statement
+	tableName := createStmt.GetRelation().GetRelname()
+	for _, n := range ctx.AllStatements {
+		alterTableStmt := n.GetAlterTableStmt()
+		if alterTableStmt == nil || alterTableStmt.GetRelation().GetRelname() != tableName {
+			continue
+		}
+		for _, cmd := range alterTableStmt.GetCmds() {
+			alterTableCmd := cmd.GetAlterTableCmd()
+			if alterTableCmd.GetSubtype() == pg_query.AlterTableType_AT_AddConstraint &&
+				alterTableCmd.GetDef().GetConstraint().GetContype() == pg_query.ConstrType_CONSTR_PRIMARY {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 type ColumnComment struct{}
 
 func (r ColumnComment) Alias() string {
@@ -80,7 +265,7 @@ func (r ColumnComment) Documentation() string {
 	return "Newly added columns should also include a COMMENT for documentation purposes."
 }
 
-func (r ColumnComment) Process(node *pg_query.Node, allNodes []*pg_query.Node, _ bool) bool {
+func (r ColumnComment) Process(node *pg_query.Node, ctx MigrationContext) bool {
 	alterTable := node.GetAlterTableStmt()
 	if alterTable == nil {
 		return false
@@ -99,7 +284,7 @@ func (r ColumnComment) Process(node *pg_query.Node, allNodes []*pg_query.Node, _
 	if colNames.Cardinality() == 0 {
 		return false
 	}
-	for _, n := range allNodes {
+	for _, n := range ctx.AllStatements {
 		comment := n.GetCommentStmt()
 		if comment == nil {
 			continue
@@ -122,7 +307,9 @@ func (r ColumnComment) Process(node *pg_query.Node, allNodes []*pg_query.Node, _
 	return true
 }
 
-type ColumnSetNotNull struct{}
+type ColumnSetNotNull struct {
+	NewTableExemption `yaml:",inline"`
+}
 
 func (r ColumnSetNotNull) Alias() string {
 	return HighAvailabilityRule("alter-column-not-null-exclusive-lock")
@@ -137,7 +324,17 @@ func (r ColumnSetNotNull) Documentation() string {
 	return "Setting a column as NOT NULL acquires an exclusive lock on the table until the constraint is validated on all table rows."
 }
 
-func (r ColumnSetNotNull) Process(node *pg_query.Node, allNodes []*pg_query.Node, _ bool) bool {
+func (r ColumnSetNotNull) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r ColumnSetNotNull) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
 	alterTableStmt := node.GetAlterTableStmt()
 	if alterTableStmt == nil {
 		return false
@@ -155,7 +352,7 @@ func (r ColumnSetNotNull) Process(node *pg_query.Node, allNodes []*pg_query.Node
 	if colName == "" {
 		return false
 	}
-	for _, n := range allNodes {
+	for _, n := range ctx.AllStatements {
 		alterTable := n.GetAlterTableStmt()
 		if alterTable == nil || alterTable.GetRelation().GetRelname() != tableName {
 			continue
@@ -178,3 +375,179 @@ func (r ColumnSetNotNull) Process(node *pg_query.Node, allNodes []*pg_query.Node
 	}
 	return true
 }
+
+// ColumnTypeRewrite - Changing the type of a column rewrites the table, unless the types are binary-compatible.
+type ColumnTypeRewrite struct {
+	NewTableExemption `yaml:",inline"`
+}
+
+func (r ColumnTypeRewrite) Alias() string {
+	return HighAvailabilityRule("avoid-column-type-rewrite")
+}
+
+// https://www.postgresql.org/docs/current/sql-altertable.html#SQL-ALTERTABLE-NOTES
+// Changes that do not require a rewrite:
+// - varchar(n) to varchar(m) where m >= n, or to an unconstrained varchar
+// - varchar to text and text to unconstrained varchar
+// - numeric(p,s) to numeric(q,s) where q >= p, or to an unconstrained numeric
+// - varbit(n) to varbit(m) where m >= n
+// - cidr to inet
+func (r ColumnTypeRewrite) Documentation() string {
+	return "Changing the type of a column rewrites the table and its indexes while holding an ACCESS EXCLUSIVE lock, " +
+		"unless the types are binary-compatible (e.g. increasing a varchar length limit or converting varchar to text)."
+}
+
+func (r ColumnTypeRewrite) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r ColumnTypeRewrite) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	alterTableStmt := node.GetAlterTableStmt()
+	if alterTableStmt == nil {
+		return false
+	}
+	tableName := alterTableStmt.GetRelation().GetRelname()
+	for _, cmd := range alterTableStmt.GetCmds() {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		if alterTableCmd == nil || alterTableCmd.Subtype != pg_query.AlterTableType_AT_AlterColumnType {
+			continue
+		}
+		columnDef := alterTableCmd.GetDef().GetColumnDef()
+		// A USING expression computes the new values for every row
+		if columnDef.GetRawDefault() != nil {
+			return true
+		}
+		target := newColumnType(columnDef.GetTypeName())
+		var current *pg_query.TypeName
+		if table, ok := ctx.CatalogBefore(node).Table(tableName); ok {
+			if column, ok := table.Column(alterTableCmd.GetName()); ok {
+				current = column.Type
+			}
+		}
+		if current == nil {
+			if !target.mayBeBinaryCoercibleTarget() {
+				return true
+			}
+			continue
+		}
+		if !newColumnType(current).isBinaryCoercibleTo(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultStableFunctions contains the functions that are evaluated once per statement, rather than for each row,
+// when used in a column default expression: the stable date and time functions, and common immutable functions.
+var DefaultStableFunctions = []string{
+	"abs",
+	"concat",
+	"current_setting",
+	"date_trunc",
+	"json_build_array",
+	"json_build_object",
+	"jsonb_build_array",
+	"jsonb_build_object",
+	"length",
+	"lower",
+	"make_date",
+	"make_interval",
+	"make_time",
+	"make_timestamp",
+	"make_timestamptz",
+	"now",
+	"statement_timestamp",
+	"timezone",
+	"to_char",
+	"to_date",
+	"to_timestamp",
+	"transaction_timestamp",
+	"upper",
+}
+
+// VolatileColumnDefault - Adding a column with a volatile default value rewrites the table.
+type VolatileColumnDefault struct {
+	NewTableExemption `yaml:",inline"`
+	// StableFunctions contains the functions allowed in default expressions, any other function call is reported.
+	StableFunctions []string `yaml:"stable-functions"`
+	// VolatileFunctions contains functions that are reported even when they are listed as stable.
+	VolatileFunctions []string `yaml:"volatile-functions"`
+}
+
+func (r VolatileColumnDefault) Alias() string {
+	return HighAvailabilityRule("avoid-volatile-column-default")
+}
+
+// https://www.postgresql.org/docs/current/ddl-alter.html#DDL-ALTER-ADDING-A-COLUMN
+// Since PostgreSQL 11, adding a column with a non-volatile default value is a metadata-only change.
+// Stable functions such as now() are evaluated once, when the statement is executed.
+// Functions are not known to be stable unless listed, so user-defined functions are reported.
+func (r VolatileColumnDefault) Documentation() string {
+	return "Adding a column with a volatile default value (e.g. random(), clock_timestamp(), gen_random_uuid(), nextval() or any function not known to be stable) " +
+		"rewrites the entire table while holding an ACCESS EXCLUSIVE lock. " +
+		"Add the column without a default, set the default in a separate statement and backfill existing rows in batches."
+}
+
+func (r VolatileColumnDefault) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r VolatileColumnDefault) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	alterTable := node.GetAlterTableStmt()
+	if alterTable == nil {
+		return false
+	}
+	stableFunctions := mapset.NewSet[string]()
+	for _, f := range r.StableFunctions {
+		stableFunctions.Add(strings.ToLower(f))
+	}
+	for _, f := range r.VolatileFunctions {
+		stableFunctions.Remove(strings.ToLower(f))
+	}
+	for _, cmd := range alterTable.GetCmds() {
+		alterTableCmd := cmd.GetAlterTableCmd()
+		if alterTableCmd == nil || alterTableCmd.Subtype != pg_query.AlterTableType_AT_AddColumn {
+			continue
+		}
+		columnDef := alterTableCmd.GetDef().GetColumnDef()
+		// Serial types are backed by a sequence and a nextval() default expression
+		if !stableFunctions.Contains("nextval") && isSerialType(newColumnType(columnDef.GetTypeName())) {
+			return true
+		}
+		for _, constraint := range columnDef.GetConstraints() {
+			if constraint.GetConstraint().GetContype() != pg_query.ConstrType_CONSTR_DEFAULT {
+				continue
+			}
+			// Before PostgreSQL 11, adding a column with any non-null default value rewrites the table
+			if ctx.PostgresVersion != 0 && ctx.PostgresVersion < 11 && !constraint.GetConstraint().GetRawExpr().GetAConst().GetIsnull() {
+				return true
+			}
+			var volatile bool
+			walk(constraint.GetConstraint().GetRawExpr(), func(n *pg_query.Node) bool {
+				if funcCall := n.GetFuncCall(); funcCall != nil {
+					names := funcCall.GetFuncname()
+					if len(names) > 0 && !stableFunctions.Contains(strings.ToLower(names[len(names)-1].GetString_().GetSval())) {
+						volatile = true
+					}
+				}
+				return !volatile
+			})
+			if volatile {
+				return true
+			}
+		}
+	}
+	return false
+}
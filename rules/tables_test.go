@@ -2,6 +2,7 @@ package rules
 
 import (
 	pg_query "github.com/pganalyze/pg_query_go/v4"
+	migrate "github.com/rubenv/sql-migrate"
 	"github.com/stretchr/testify/assert"
 	"strings"
 	"testing"
@@ -42,7 +43,377 @@ COMMENT ON COLUMN movies.released_at IS 'First release date';`,
 			for _, statement := range strings.Split(tt.sql, "\n") {
 				allNodes = append(allNodes, parseStatement(t, statement))
 			}
-			assert.Equal(t, tt.want, r.Process(allNodes[0], allNodes, true))
+			assert.Equal(t, tt.want, r.Process(allNodes[0], MigrationContext{AllStatements: allNodes, InTransaction: true}))
+		})
+	}
+}
+
+func TestColumnTypeRewrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "column type change to a type that always requires a rewrite",
+			sql:  `ALTER TABLE movies ALTER COLUMN rating TYPE bigint;`,
+			want: true,
+		},
+		{
+			name: "column type change with a USING expression",
+			sql:  `ALTER TABLE movies ALTER COLUMN rating TYPE numeric(10,2) USING rating::numeric;`,
+			want: true,
+		},
+		{
+			name: "column type change to text when the current type is unknown",
+			sql:  `ALTER TABLE movies ALTER COLUMN title TYPE text;`,
+			want: false,
+		},
+		{
+			name: "increasing varchar length",
+			sql: `CREATE TABLE movies (title varchar(100));
+ALTER TABLE movies ALTER COLUMN title TYPE varchar(200);`,
+			want: false,
+		},
+		{
+			name: "decreasing varchar length",
+			sql: `CREATE TABLE movies (title varchar(100));
+ALTER TABLE movies ALTER COLUMN title TYPE varchar(50);`,
+			want: true,
+		},
+		{
+			name: "varchar to text",
+			sql: `ALTER TABLE movies ADD COLUMN title character varying(100);
+ALTER TABLE movies ALTER COLUMN title TYPE text;`,
+			want: false,
+		},
+		{
+			name: "text to limited varchar",
+			sql: `CREATE TABLE movies (id bigint PRIMARY KEY);
+ALTER TABLE movies ADD COLUMN title text;
+ALTER TABLE movies ALTER COLUMN title TYPE varchar(10);`,
+			want: true,
+		},
+		{
+			name: "increasing numeric precision with the same scale",
+			sql: `CREATE TABLE movies (rating numeric(4,2));
+ALTER TABLE movies ALTER COLUMN rating TYPE numeric(6,2);`,
+			want: false,
+		},
+		{
+			name: "changing numeric scale",
+			sql: `CREATE TABLE movies (rating numeric(4,2));
+ALTER TABLE movies ALTER COLUMN rating TYPE numeric(6,3);`,
+			want: true,
+		},
+		{
+			name: "integer to text",
+			sql: `CREATE TABLE movies (rating integer);
+ALTER TABLE movies ALTER COLUMN rating TYPE text;`,
+			want: true,
+		},
+		{
+			name: "column definition found in another table",
+			sql: `CREATE TABLE films (rating integer);
+ALTER TABLE movies ALTER COLUMN rating TYPE numeric;`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The column types are defined in the same migration
+			r := ColumnTypeRewrite{NewTableExemption: NewTableExemption{CheckNewTables: true}}
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			assert.Equal(t, tt.want, r.Process(allNodes[len(allNodes)-1], MigrationContext{AllStatements: allNodes, InTransaction: true}))
+		})
+	}
+}
+
+func TestVolatileColumnDefault(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		sql               string
+		stableFunctions   []string
+		volatileFunctions []string
+		postgresVersion   int
+		want              bool
+	}{
+		{
+			name: "constant default value",
+			sql:  `ALTER TABLE movies ADD COLUMN rating integer NOT NULL DEFAULT 0;`,
+			want: false,
+		},
+		{
+			name: "stable function default value",
+			sql:  `ALTER TABLE movies ADD COLUMN created_at timestamptz DEFAULT now();`,
+			want: false,
+		},
+		{
+			name: "CURRENT_TIMESTAMP default value",
+			sql:  `ALTER TABLE movies ADD COLUMN created_at timestamptz DEFAULT CURRENT_TIMESTAMP;`,
+			want: false,
+		},
+		{
+			name: "volatile function default value",
+			sql:  `ALTER TABLE movies ADD COLUMN uuid uuid DEFAULT gen_random_uuid();`,
+			want: true,
+		},
+		{
+			name: "schema-qualified volatile function default value",
+			sql:  `ALTER TABLE movies ADD COLUMN created_at timestamptz DEFAULT pg_catalog.clock_timestamp();`,
+			want: true,
+		},
+		{
+			name: "nested volatile function call",
+			sql:  `ALTER TABLE movies ADD COLUMN token text DEFAULT md5(random()::text);`,
+			want: true,
+		},
+		{
+			name: "serial column",
+			sql:  `ALTER TABLE movies ADD COLUMN position bigserial;`,
+			want: true,
+		},
+		{
+			name: "user-defined function default value",
+			sql:  `ALTER TABLE movies ADD COLUMN uuid uuid DEFAULT my_uuid();`,
+			want: true,
+		},
+		{
+			name: "stable function with constant arguments",
+			sql:  `ALTER TABLE movies ADD COLUMN created_on date DEFAULT date_trunc('day', now());`,
+			want: false,
+		},
+		{
+			name:            "custom stable function list",
+			sql:             `ALTER TABLE movies ADD COLUMN token text DEFAULT default_token();`,
+			stableFunctions: []string{"default_token"},
+			want:            false,
+		},
+		{
+			name:              "custom volatile function listed as stable",
+			sql:               `ALTER TABLE movies ADD COLUMN created_at timestamptz DEFAULT now();`,
+			volatileFunctions: []string{"now"},
+			want:              true,
+		},
+		{
+			name:            "constant default value before PostgreSQL 11",
+			sql:             `ALTER TABLE movies ADD COLUMN rating integer NOT NULL DEFAULT 0;`,
+			postgresVersion: 10,
+			want:            true,
+		},
+		{
+			name:            "null default value before PostgreSQL 11",
+			sql:             `ALTER TABLE movies ADD COLUMN rating integer DEFAULT NULL;`,
+			postgresVersion: 10,
+			want:            false,
+		},
+		{
+			name:            "constant default value since PostgreSQL 11",
+			sql:             `ALTER TABLE movies ADD COLUMN rating integer NOT NULL DEFAULT 0;`,
+			postgresVersion: 11,
+			want:            false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := VolatileColumnDefault{StableFunctions: DefaultStableFunctions, VolatileFunctions: tt.volatileFunctions}
+			if tt.stableFunctions != nil {
+				r.StableFunctions = tt.stableFunctions
+			}
+			node := parseStatement(t, tt.sql)
+			assert.Equal(t, tt.want, r.Process(node, MigrationContext{
+				AllStatements:   []*pg_query.Node{node},
+				InTransaction:   true,
+				PostgresVersion: tt.postgresVersion,
+			}))
+		})
+	}
+}
+
+func TestDropTable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sql       string
+		direction migrate.MigrationDirection
+		want      bool
+	}{
+		{
+			name:      "table dropped in forward migration",
+			sql:       `DROP TABLE movies;`,
+			direction: migrate.Up,
+			want:      true,
+		},
+		{
+			name:      "table dropped in rollback migration",
+			sql:       `DROP TABLE IF EXISTS movies;`,
+			direction: migrate.Down,
+			want:      false,
+		},
+		{
+			name:      "index dropped in forward migration",
+			sql:       `DROP INDEX movies_title_idx;`,
+			direction: migrate.Up,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := DropTable{}
+			node := parseStatement(t, tt.sql)
+			assert.Equal(t, tt.want, r.Process(node, MigrationContext{Direction: tt.direction}))
+		})
+	}
+}
+
+func TestDropColumn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		sql       string
+		direction migrate.MigrationDirection
+		want      bool
+	}{
+		{
+			name:      "column dropped in forward migration",
+			sql:       `ALTER TABLE movies ADD COLUMN rating integer, DROP COLUMN released_at;`,
+			direction: migrate.Up,
+			want:      true,
+		},
+		{
+			name:      "column dropped in rollback migration",
+			sql:       `ALTER TABLE movies DROP COLUMN released_at;`,
+			direction: migrate.Down,
+			want:      false,
+		},
+		{
+			name:      "column added in forward migration",
+			sql:       `ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			direction: migrate.Up,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := DropColumn{}
+			node := parseStatement(t, tt.sql)
+			assert.Equal(t, tt.want, r.Process(node, MigrationContext{Direction: tt.direction}))
+		})
+	}
+}
+
+func TestRenameRules(t *testing.T) {
+	t.Parallel()
+
+	statements := []string{
+		`ALTER TABLE movies RENAME TO films;`,
+		`ALTER TABLE movies RENAME COLUMN released_at TO release_date;`,
+		`ALTER VIEW top_movies RENAME TO best_movies;`,
+		`ALTER MATERIALIZED VIEW movie_ratings RENAME TO film_ratings;`,
+		`ALTER SEQUENCE movies_id_seq RENAME TO films_id_seq;`,
+		`ALTER SCHEMA catalog RENAME TO library;`,
+		`ALTER TABLE movies RENAME CONSTRAINT movies_pkey TO films_pkey;`,
+	}
+	tests := []struct {
+		rule Rule
+		want []bool
+	}{
+		{rule: RenameTable{}, want: []bool{true, false, false, false, false, false, false}},
+		{rule: RenameColumn{}, want: []bool{false, true, false, false, false, false, false}},
+		{rule: RenameView{}, want: []bool{false, false, true, true, false, false, false}},
+		{rule: RenameSequence{}, want: []bool{false, false, false, false, true, false, false}},
+		{rule: RenameSchema{}, want: []bool{false, false, false, false, false, true, false}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.rule.Alias(), func(t *testing.T) {
+			t.Parallel()
+
+			for i, statement := range statements {
+				assert.Equal(t, tt.want[i], tt.rule.Process(parseStatement(t, statement), MigrationContext{}), statement)
+			}
+		})
+	}
+}
+
+func TestTableRequiresPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "table without primary key",
+			sql:  `CREATE TABLE movies (id bigint NOT NULL, title text);`,
+			want: true,
+		},
+		{
+			name: "column primary key",
+			sql:  `CREATE TABLE movies (id bigint PRIMARY KEY, title text);`,
+			want: false,
+		},
+		{
+			name: "table primary key constraint",
+			sql:  `CREATE TABLE movie_ratings (movie_id bigint, user_id bigint, CONSTRAINT movie_ratings_pkey PRIMARY KEY (movie_id, user_id));`,
+			want: false,
+		},
+		{
+			name: "primary key added in a separate statement",
+			sql: `CREATE TABLE movies (id bigint NOT NULL, title text);
+ALTER TABLE movies ADD PRIMARY KEY (id);`,
+			want: false,
+		},
+		{
+			name: "primary key added to another table",
+			sql: `CREATE TABLE movies (id bigint NOT NULL, title text);
+ALTER TABLE films ADD PRIMARY KEY (id);`,
+			want: true,
+		},
+		{
+			name: "partition",
+			sql:  `CREATE TABLE ratings_2023 PARTITION OF ratings FOR VALUES FROM ('2023-01-01') TO ('2024-01-01');`,
+			want: false,
+		},
+		{
+			name: "temporary table",
+			sql:  `CREATE TEMPORARY TABLE movies_import (title text);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := TableRequiresPrimaryKey{}
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			assert.Equal(t, tt.want, r.Process(allNodes[0], MigrationContext{AllStatements: allNodes}))
 		})
 	}
 }
@@ -0,0 +1,227 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// LockTimeoutRequired - Statements waiting to acquire a lock block all the queries queued behind them.
+type LockTimeoutRequired struct {
+	NewTableExemption `yaml:",inline"`
+	MaxLockTimeout    string `yaml:"max-lock-timeout"`
+	maxLockTimeout    time.Duration
+}
+
+func (r LockTimeoutRequired) Alias() string {
+	return HighAvailabilityRule("require-lock-timeout")
+}
+
+// https://www.postgresql.org/docs/current/runtime-config-client.html#GUC-LOCK-TIMEOUT
+func (r LockTimeoutRequired) Documentation() string {
+	return "Statements that acquire a table lock must be preceded by a SET lock_timeout (or SET LOCAL lock_timeout) statement. " +
+		"While waiting for the lock, all the queries on the table are queued behind the migration, even if the statement itself is instant."
+}
+
+func (r LockTimeoutRequired) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	if r.MaxLockTimeout != "" {
+		maxLockTimeout, err := parseTimeout(r.MaxLockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		r.maxLockTimeout = maxLockTimeout
+	}
+	return r, nil
+}
+
+func (r LockTimeoutRequired) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	if !acquiresTableLock(node) {
+		return false
+	}
+	timeout, ok, err := timeoutSetting("lock_timeout", ctx.PrecedingStatements(node), ctx.InTransaction)
+	if !ok || err != nil || timeout == 0 {
+		return true
+	}
+	return r.maxLockTimeout > 0 && timeout > r.maxLockTimeout
+}
+
+func (r LockTimeoutRequired) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	return invalidTimeoutExplanation("lock_timeout", node, ctx)
+}
+
+// invalidTimeoutExplanation explains that the value of the timeout setting in effect before the statement cannot be parsed.
+func invalidTimeoutExplanation(name string, node *pg_query.Node, ctx MigrationContext) string {
+	_, _, err := timeoutSetting(name, ctx.PrecedingStatements(node), ctx.InTransaction)
+	if err == nil {
+		return ""
+	}
+	return fmt.Sprintf("The %s value cannot be parsed: %v. Use a number with one of the units us, ms, s, min, h or d.", name, err)
+}
+
+// timeoutSetting returns the value of the timeout setting that is in effect after the given statements are executed,
+// along with the parsing error of the value, if any.
+// Settings defined with SET LOCAL only apply until the end of the current transaction.
+func timeoutSetting(name string, statements []*pg_query.Node, inTransaction bool) (time.Duration, bool, error) {
+	var (
+		timeout      time.Duration
+		found        bool
+		invalid      error
+		local        bool
+		inBlock      = inTransaction
+		sessionValue time.Duration
+		sessionFound bool
+		sessionError error
+	)
+	for _, n := range statements {
+		if transactionStmt := n.GetTransactionStmt(); transactionStmt != nil && !inTransaction {
+			switch transactionStmt.GetKind() {
+			case pg_query.TransactionStmtKind_TRANS_STMT_BEGIN, pg_query.TransactionStmtKind_TRANS_STMT_START:
+				inBlock = true
+			case pg_query.TransactionStmtKind_TRANS_STMT_COMMIT, pg_query.TransactionStmtKind_TRANS_STMT_ROLLBACK:
+				inBlock = false
+				if local {
+					timeout, found, invalid, local = sessionValue, sessionFound, sessionError, false
+				}
+			}
+			continue
+		}
+		variableSetStmt := n.GetVariableSetStmt()
+		if variableSetStmt == nil {
+			continue
+		}
+		if variableSetStmt.GetKind() == pg_query.VariableSetKind_VAR_RESET_ALL {
+			timeout, found, invalid, local = 0, false, nil, false
+			sessionValue, sessionFound, sessionError = 0, false, nil
+			continue
+		}
+		if variableSetStmt.GetName() != name {
+			continue
+		}
+		switch variableSetStmt.GetKind() {
+		case pg_query.VariableSetKind_VAR_SET_VALUE:
+			value, err := timeoutSettingValue(variableSetStmt.GetArgs())
+			if variableSetStmt.GetIsLocal() {
+				// SET LOCAL has no effect outside a transaction block
+				if inBlock {
+					timeout, found, invalid, local = value, true, err, true
+				}
+				continue
+			}
+			timeout, found, invalid, local = value, true, err, false
+			sessionValue, sessionFound, sessionError = value, true, err
+		case pg_query.VariableSetKind_VAR_SET_DEFAULT, pg_query.VariableSetKind_VAR_RESET:
+			timeout, found, invalid, local = 0, false, nil, false
+			sessionValue, sessionFound, sessionError = 0, false, nil
+		}
+	}
+	return timeout, found, invalid
+}
+
+func timeoutSettingValue(args []*pg_query.Node) (time.Duration, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected a single value")
+	}
+	value := args[0].GetAConst()
+	if value.GetSval() != nil {
+		return parseTimeout(value.GetSval().GetSval())
+	}
+	if value.GetIval() != nil {
+		return time.Duration(value.GetIval().GetIval()) * time.Millisecond, nil
+	}
+	if value.GetFval() != nil {
+		return parseTimeout(value.GetFval().GetFval())
+	}
+	return 0, fmt.Errorf("unsupported value")
+}
+
+var timeoutPattern = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)\s*(us|ms|s|min|h|d)?$`)
+
+// parseTimeout parses a PostgreSQL time setting value, e.g. 500ms, 1.5s, 1min.
+// Values without a unit are in milliseconds, fractional values are rounded to the nearest millisecond like PostgreSQL does.
+func parseTimeout(value string) (time.Duration, error) {
+	m := timeoutPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, fmt.Errorf("invalid timeout value %q", value)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	units := map[string]time.Duration{
+		"us":  time.Microsecond,
+		"":    time.Millisecond,
+		"ms":  time.Millisecond,
+		"s":   time.Second,
+		"min": time.Minute,
+		"h":   time.Hour,
+		"d":   24 * time.Hour,
+	}
+	return time.Duration(math.RoundToEven(n*float64(units[m[2]])/float64(time.Millisecond))) * time.Millisecond, nil
+}
+
+// StatementTimeoutRequired - Long-running statements must define their own time budget.
+type StatementTimeoutRequired struct {
+	NewTableExemption `yaml:",inline"`
+}
+
+func (r StatementTimeoutRequired) Alias() string {
+	return HighAvailabilityRule("require-statement-timeout")
+}
+
+// https://www.postgresql.org/docs/current/runtime-config-client.html#GUC-STATEMENT-TIMEOUT
+func (r StatementTimeoutRequired) Documentation() string {
+	return "Long-running statements, such as whole-table UPDATE/DELETE, constraint validation and non-concurrent index builds, " +
+		"must be preceded by a SET statement_timeout (or SET LOCAL statement_timeout) statement that defines the time budget of the migration."
+}
+
+func (r StatementTimeoutRequired) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r StatementTimeoutRequired) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	if !isLongRunning(node) {
+		return false
+	}
+	timeout, ok, err := timeoutSetting("statement_timeout", ctx.PrecedingStatements(node), ctx.InTransaction)
+	return !ok || err != nil || timeout == 0
+}
+
+func (r StatementTimeoutRequired) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	return invalidTimeoutExplanation("statement_timeout", node, ctx)
+}
+
+// isLongRunning reports whether the statement processes all the rows of a table.
+func isLongRunning(node *pg_query.Node) bool {
+	if updateStmt := node.GetUpdateStmt(); updateStmt != nil {
+		return updateStmt.GetWhereClause() == nil
+	}
+	if deleteStmt := node.GetDeleteStmt(); deleteStmt != nil {
+		return deleteStmt.GetWhereClause() == nil
+	}
+	if indexStmt := node.GetIndexStmt(); indexStmt != nil {
+		return !indexStmt.GetConcurrent()
+	}
+	for _, cmd := range node.GetAlterTableStmt().GetCmds() {
+		if cmd.GetAlterTableCmd().GetSubtype() == pg_query.AlterTableType_AT_ValidateConstraint {
+			return true
+		}
+	}
+	return false
+}
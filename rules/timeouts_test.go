@@ -0,0 +1,318 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockTimeoutRequired_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		sql            string
+		inTransaction  bool
+		maxLockTimeout string
+		want           bool
+	}{
+		{
+			name:          "lock timeout is not set",
+			sql:           `ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction: true,
+			want:          true,
+		},
+		{
+			name: "lock timeout is set",
+			sql: `SET lock_timeout = '5s';
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "lock timeout is set locally in a transaction",
+			sql: `SET LOCAL lock_timeout TO 5000;
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "lock timeout is set locally outside a transaction",
+			sql: `SET LOCAL lock_timeout TO 5000;
+CREATE INDEX movies_title_idx ON movies (title);`,
+			inTransaction: false,
+			want:          true,
+		},
+		{
+			name: "lock timeout is set locally in a transaction that has been committed",
+			sql: `BEGIN;
+SET LOCAL lock_timeout TO '5s';
+COMMIT;
+DROP TABLE movies;`,
+			inTransaction: false,
+			want:          true,
+		},
+		{
+			name: "lock timeout is reset",
+			sql: `SET lock_timeout = '5s';
+RESET lock_timeout;
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction: true,
+			want:          true,
+		},
+		{
+			name: "lock timeout is set with a decimal value",
+			sql: `SET lock_timeout = '1.5s';
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction:  true,
+			maxLockTimeout: "2s",
+			want:           false,
+		},
+		{
+			name: "lock timeout cannot be parsed",
+			sql: `SET lock_timeout = '5 seconds';
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction: true,
+			want:          true,
+		},
+		{
+			name: "lock timeout is disabled",
+			sql: `SET lock_timeout = 0;
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction: true,
+			want:          true,
+		},
+		{
+			name: "lock timeout exceeds the maximum",
+			sql: `SET lock_timeout = '1min';
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction:  true,
+			maxLockTimeout: "10s",
+			want:           true,
+		},
+		{
+			name: "lock timeout does not exceed the maximum",
+			sql: `SET lock_timeout = '2s';
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			inTransaction:  true,
+			maxLockTimeout: "10s",
+			want:           false,
+		},
+		{
+			name:          "concurrent index creation",
+			sql:           `CREATE INDEX CONCURRENTLY movies_title_idx ON movies (title);`,
+			inTransaction: false,
+			want:          false,
+		},
+		{
+			name:          "statement does not acquire a table lock",
+			sql:           `UPDATE movies SET rating = 0 WHERE rating IS NULL;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name:          "table rename without lock timeout",
+			sql:           `ALTER TABLE movies RENAME COLUMN title TO name;`,
+			inTransaction: true,
+			want:          true,
+		},
+		{
+			name:          "trigger disabled without lock timeout",
+			sql:           `ALTER TABLE movies DISABLE TRIGGER movies_audit;`,
+			inTransaction: true,
+			want:          true,
+		},
+		{
+			name:          "storage parameter change does not block writes",
+			sql:           `ALTER TABLE movies SET (fillfactor = 70);`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name:          "type rename does not acquire a table lock",
+			sql:           `ALTER TYPE mood RENAME TO feeling;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name:          "schema rename does not acquire a table lock",
+			sql:           `ALTER SCHEMA archive RENAME TO history;`,
+			inTransaction: true,
+			want:          false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule, err := LockTimeoutRequired{}.Configure(func(v any) error {
+				v.(*LockTimeoutRequired).MaxLockTimeout = tt.maxLockTimeout
+				return nil
+			})
+			require.NoError(t, err)
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			assert.Equal(t, tt.want, rule.Process(allNodes[len(allNodes)-1], MigrationContext{
+				AllStatements: allNodes,
+				InTransaction: tt.inTransaction,
+			}))
+		})
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{value: "100", want: 100 * time.Millisecond, wantErr: assert.NoError},
+		{value: "500ms", want: 500 * time.Millisecond, wantErr: assert.NoError},
+		{value: "5s", want: 5 * time.Second, wantErr: assert.NoError},
+		{value: "2 min", want: 2 * time.Minute, wantErr: assert.NoError},
+		{value: "1h", want: time.Hour, wantErr: assert.NoError},
+		{value: "1.5s", want: 1500 * time.Millisecond, wantErr: assert.NoError},
+		{value: "0.5min", want: 30 * time.Second, wantErr: assert.NoError},
+		{value: "2.5", want: 2 * time.Millisecond, wantErr: assert.NoError},
+		{value: "1.5.0s", wantErr: assert.Error},
+		{value: "5 seconds", wantErr: assert.Error},
+		{value: "", wantErr: assert.Error},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseTimeout(tt.value)
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLockTimeoutRequired_Explain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "lock timeout is not set",
+			sql:  `ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			want: "",
+		},
+		{
+			name: "lock timeout cannot be parsed",
+			sql: `SET lock_timeout = '5 seconds';
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			want: `The lock_timeout value cannot be parsed: invalid timeout value "5 seconds". Use a number with one of the units us, ms, s, min, h or d.`,
+		},
+		{
+			name: "lock timeout is set again with a valid value",
+			sql: `SET lock_timeout = '5 seconds';
+SET lock_timeout = '5s';
+ALTER TABLE movies ADD COLUMN released_at timestamptz;`,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			assert.Equal(t, tt.want, LockTimeoutRequired{}.Explain(allNodes[len(allNodes)-1], MigrationContext{
+				AllStatements: allNodes,
+				InTransaction: true,
+			}))
+		})
+	}
+}
+
+func TestStatementTimeoutRequired_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{
+			name: "whole-table update without statement timeout",
+			sql:  `UPDATE movies SET rating = 0;`,
+			want: true,
+		},
+		{
+			name: "whole-table delete without statement timeout",
+			sql:  `DELETE FROM movies;`,
+			want: true,
+		},
+		{
+			name: "constraint validation without statement timeout",
+			sql:  `ALTER TABLE movies VALIDATE CONSTRAINT rating_not_null;`,
+			want: true,
+		},
+		{
+			name: "non-concurrent index creation without statement timeout",
+			sql:  `CREATE INDEX movies_title_idx ON movies (title);`,
+			want: true,
+		},
+		{
+			name: "whole-table update with statement timeout",
+			sql: `SET LOCAL statement_timeout = '15min';
+UPDATE movies SET rating = 0;`,
+			want: false,
+		},
+		{
+			name: "whole-table update with a decimal statement timeout",
+			sql: `SET statement_timeout = 1.5;
+UPDATE movies SET rating = 0;`,
+			want: false,
+		},
+		{
+			name: "whole-table update with disabled statement timeout",
+			sql: `SET statement_timeout = 0;
+UPDATE movies SET rating = 0;`,
+			want: true,
+		},
+		{
+			name: "update with a WHERE clause",
+			sql:  `UPDATE movies SET rating = 0 WHERE id = 1;`,
+			want: false,
+		},
+		{
+			name: "concurrent index creation",
+			sql:  `CREATE INDEX CONCURRENTLY movies_title_idx ON movies (title);`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := StatementTimeoutRequired{}
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			assert.Equal(t, tt.want, r.Process(allNodes[len(allNodes)-1], MigrationContext{
+				AllStatements: allNodes,
+				InTransaction: true,
+			}))
+		})
+	}
+}
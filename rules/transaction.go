@@ -1,7 +1,10 @@
 package rules
 
 import (
+	"strings"
+
 	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/locks"
 )
 
 type NestedTransaction struct{}
@@ -14,8 +17,8 @@ func (r NestedTransaction) Documentation() string {
 	return "Nested transactions are not supported in PostgreSQL."
 }
 
-func (r NestedTransaction) Process(node *pg_query.Node, _ []*pg_query.Node, inTransaction bool) bool {
-	if !inTransaction {
+func (r NestedTransaction) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if !ctx.InTransaction {
 		return false
 	}
 	transactionStmt := node.GetTransactionStmt()
@@ -41,14 +44,17 @@ func (t TransactionNotSupportedInConcurrentIndexOperations) Alias() string {
 	return TransactionRule("concurrent-index-operation-cannot-be-executed-in-transaction")
 }
 
-func (t TransactionNotSupportedInConcurrentIndexOperations) Process(node *pg_query.Node, _ []*pg_query.Node, inTransaction bool) bool {
-	if !inTransaction {
+func (t TransactionNotSupportedInConcurrentIndexOperations) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if !ctx.InTransaction {
 		return false
 	}
 	indexStmt := node.GetIndexStmt()
 	if indexStmt != nil {
 		return indexStmt.Concurrent
 	}
+	if reindexStmt := node.GetReindexStmt(); reindexStmt != nil {
+		return locks.HasOption(reindexStmt.GetParams(), "concurrently")
+	}
 
 	dropStmt := node.GetDropStmt()
 	if dropStmt == nil {
@@ -59,3 +65,118 @@ func (t TransactionNotSupportedInConcurrentIndexOperations) Process(node *pg_que
 	}
 	return dropStmt.GetConcurrent()
 }
+
+// DDLAndDMLMix - Backfills executed in the same transaction as DDL statements hold the DDL table locks for their entire duration.
+type DDLAndDMLMix struct{}
+
+func (r DDLAndDMLMix) Alias() string {
+	return TransactionRule("no-ddl-and-dml-mix")
+}
+
+func (r DDLAndDMLMix) Documentation() string {
+	return "Data modification statements (backfills) executed in the same transaction as DDL statements that acquire an ACCESS EXCLUSIVE lock " +
+		"keep the table locked until the backfill completes. Run backfills in a separate migration."
+}
+
+func (r DDLAndDMLMix) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if !isBackfill(node, ctx) {
+		return false
+	}
+	preceding, inTransaction := transactionStatements(node, ctx)
+	return inTransaction && len(accessExclusiveStatements(preceding)) > 0
+}
+
+func (r DDLAndDMLMix) Explain(node *pg_query.Node, ctx MigrationContext) string {
+	preceding, _ := transactionStatements(node, ctx)
+	var statements []string
+	for _, n := range accessExclusiveStatements(preceding) {
+		statements = append(statements, deparse(n))
+	}
+	return "ACCESS EXCLUSIVE lock acquired in the same transaction by: " + strings.Join(statements, "; ")
+}
+
+// isBackfill reports whether the statement modifies rows of a table or copies rows from another table.
+// Updates and deletes of a single row, filtered by equality on the id or primary key column, are not backfills.
+func isBackfill(node *pg_query.Node, ctx MigrationContext) bool {
+	if updateStmt := node.GetUpdateStmt(); updateStmt != nil {
+		return !isSingleRowFilter(updateStmt.GetWhereClause(), updateStmt.GetRelation().GetRelname(), node, ctx)
+	}
+	if deleteStmt := node.GetDeleteStmt(); deleteStmt != nil {
+		return !isSingleRowFilter(deleteStmt.GetWhereClause(), deleteStmt.GetRelation().GetRelname(), node, ctx)
+	}
+	if insertStmt := node.GetInsertStmt(); insertStmt != nil {
+		selectStmt := insertStmt.GetSelectStmt().GetSelectStmt()
+		return selectStmt != nil && len(selectStmt.GetValuesLists()) == 0
+	}
+	return false
+}
+
+// isSingleRowFilter reports whether the WHERE clause compares the id column, or the single-column primary key
+// of the table, with a constant or a parameter, alone or as one of the AND conditions.
+func isSingleRowFilter(where *pg_query.Node, table string, node *pg_query.Node, ctx MigrationContext) bool {
+	if boolExpr := where.GetBoolExpr(); boolExpr.GetBoolop() == pg_query.BoolExprType_AND_EXPR {
+		for _, arg := range boolExpr.GetArgs() {
+			if isSingleRowFilter(arg, table, node, ctx) {
+				return true
+			}
+		}
+		return false
+	}
+	aExpr := where.GetAExpr()
+	if aExpr.GetKind() != pg_query.A_Expr_Kind_AEXPR_OP || lastName(aExpr.GetName()) != "=" {
+		return false
+	}
+	for _, sides := range [][2]*pg_query.Node{{aExpr.GetLexpr(), aExpr.GetRexpr()}, {aExpr.GetRexpr(), aExpr.GetLexpr()}} {
+		column, value := sides[0].GetColumnRef(), sides[1]
+		if column == nil || (value.GetAConst() == nil && value.GetParamRef() == nil) {
+			continue
+		}
+		name := lastName(column.GetFields())
+		if name == "id" {
+			return true
+		}
+		if t, ok := ctx.CatalogBefore(node).Table(table); ok {
+			if pk, ok := t.PrimaryKey(); ok && len(pk.Columns) == 1 && pk.Columns[0] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func accessExclusiveStatements(statements []*pg_query.Node) []*pg_query.Node {
+	var matching []*pg_query.Node
+	for _, n := range statements {
+		if acquiresAccessExclusiveLock(n) {
+			matching = append(matching, n)
+		}
+	}
+	return matching
+}
+
+// transactionStatements returns the statements preceding the given statement that are executed in the same transaction.
+// Returns false if the statement is not executed within a transaction, either from the migration tool or an explicit transaction block.
+func transactionStatements(node *pg_query.Node, ctx MigrationContext) ([]*pg_query.Node, bool) {
+	preceding := ctx.PrecedingStatements(node)
+	if ctx.InTransaction {
+		return preceding, true
+	}
+	var (
+		statements []*pg_query.Node
+		inBlock    bool
+	)
+	for _, n := range preceding {
+		switch n.GetTransactionStmt().GetKind() {
+		case pg_query.TransactionStmtKind_TRANS_STMT_BEGIN, pg_query.TransactionStmtKind_TRANS_STMT_START:
+			statements, inBlock = nil, true
+			continue
+		case pg_query.TransactionStmtKind_TRANS_STMT_COMMIT, pg_query.TransactionStmtKind_TRANS_STMT_ROLLBACK:
+			statements, inBlock = nil, false
+			continue
+		}
+		if inBlock {
+			statements = append(statements, n)
+		}
+	}
+	return statements, inBlock
+}
@@ -45,7 +45,173 @@ func TestNestedTransaction_Process(t *testing.T) {
 			for _, statement := range strings.Split(tt.sql, "\n") {
 				allNodes = append(allNodes, parseStatement(t, statement))
 			}
-			assert.Equal(t, tt.want, r.Process(allNodes[0], allNodes, true))
+			assert.Equal(t, tt.want, r.Process(allNodes[0], MigrationContext{AllStatements: allNodes, InTransaction: true}))
+		})
+	}
+}
+
+func TestDDLAndDMLMix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		sql           string
+		inTransaction bool
+		want          bool
+		wantDetails   string
+	}{
+		{
+			name: "backfill after column addition",
+			sql: `ALTER TABLE movies ADD COLUMN rating integer;
+UPDATE movies SET rating = 0 WHERE rating IS NULL;`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "ACCESS EXCLUSIVE lock acquired in the same transaction by: ALTER TABLE movies ADD COLUMN rating int",
+		},
+		{
+			name: "rows copied from another table",
+			sql: `ALTER TABLE movies RENAME TO films;
+DROP TABLE directors;
+INSERT INTO movies_archive SELECT * FROM films;`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "ACCESS EXCLUSIVE lock acquired in the same transaction by: ALTER TABLE movies RENAME TO films; DROP TABLE directors",
+		},
+		{
+			name: "backfill without transaction",
+			sql: `ALTER TABLE movies ADD COLUMN rating integer;
+UPDATE movies SET rating = 0 WHERE rating IS NULL;`,
+			inTransaction: false,
+			want:          false,
+		},
+		{
+			name: "backfill in an explicit transaction block without transaction",
+			sql: `BEGIN;
+ALTER TABLE movies ADD COLUMN rating integer;
+DELETE FROM reviews;
+COMMIT;`,
+			inTransaction: false,
+			want:          true,
+			wantDetails:   "ACCESS EXCLUSIVE lock acquired in the same transaction by: ALTER TABLE movies ADD COLUMN rating int",
+		},
+		{
+			name: "backfill after an explicit transaction block without transaction",
+			sql: `BEGIN;
+ALTER TABLE movies ADD COLUMN rating integer;
+COMMIT;
+UPDATE movies SET rating = 0;`,
+			inTransaction: false,
+			want:          false,
+		},
+		{
+			name: "DDL after the backfill",
+			sql: `UPDATE movies SET rating = 0;
+ALTER TABLE movies ADD COLUMN budget integer;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "unfiltered backfill after column addition",
+			sql: `ALTER TABLE movies ADD COLUMN rating integer;
+UPDATE movies SET rating = 0;`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "ACCESS EXCLUSIVE lock acquired in the same transaction by: ALTER TABLE movies ADD COLUMN rating int",
+		},
+		{
+			name: "update of a single row",
+			sql: `ALTER TABLE movies ADD COLUMN rating integer;
+UPDATE movies SET rating = 1 WHERE id = 5;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "update of a single row by primary key",
+			sql: `CREATE TABLE genres (code text PRIMARY KEY, name text);
+ALTER TABLE genres ADD COLUMN rating integer;
+UPDATE genres SET rating = 1 WHERE code = 'noir' AND rating IS NULL;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "update filtered by another column",
+			sql: `CREATE TABLE genres (code text PRIMARY KEY, name text);
+ALTER TABLE genres ADD COLUMN rating integer;
+UPDATE genres SET rating = 1 WHERE name = 'noir';`,
+			inTransaction: true,
+			want:          true,
+			wantDetails:   "ACCESS EXCLUSIVE lock acquired in the same transaction by: ALTER TABLE genres ADD COLUMN rating int",
+		},
+		{
+			name: "backfill with DDL that does not acquire an ACCESS EXCLUSIVE lock",
+			sql: `ALTER TABLE movies VALIDATE CONSTRAINT rating_not_null;
+UPDATE movies SET rating = 0 WHERE rating IS NULL;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "backfill with storage parameter and trigger changes",
+			sql: `ALTER TABLE movies SET (fillfactor = 70);
+ALTER TABLE movies DISABLE TRIGGER movies_audit;
+UPDATE movies SET rating = 0 WHERE rating IS NULL;`,
+			inTransaction: true,
+			want:          false,
+		},
+		{
+			name: "insert values",
+			sql: `ALTER TABLE movies ADD COLUMN rating integer;
+INSERT INTO movies (title) VALUES ('Vertigo');`,
+			inTransaction: true,
+			want:          false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := DDLAndDMLMix{}
+			var allNodes []*pg_query.Node
+			for _, statement := range strings.Split(tt.sql, "\n") {
+				allNodes = append(allNodes, parseStatement(t, statement))
+			}
+			ctx := MigrationContext{AllStatements: allNodes, InTransaction: tt.inTransaction}
+			var backfill *pg_query.Node
+			for _, n := range allNodes {
+				if n.GetUpdateStmt() != nil || n.GetDeleteStmt() != nil || n.GetInsertStmt() != nil {
+					backfill = n
+				}
+			}
+			assert.Equal(t, tt.want, r.Process(backfill, ctx))
+			if tt.want {
+				assert.Equal(t, tt.wantDetails, r.Explain(backfill, ctx))
+			}
+		})
+	}
+}
+
+func TestTransactionNotSupportedInConcurrentIndexOperations_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		sql           string
+		inTransaction bool
+		want          bool
+	}{
+		{name: "concurrent index creation in transaction", sql: `CREATE INDEX CONCURRENTLY movies_title_idx ON movies (title);`, inTransaction: true, want: true},
+		{name: "concurrent index drop in transaction", sql: `DROP INDEX CONCURRENTLY movies_title_idx;`, inTransaction: true, want: true},
+		{name: "concurrent reindex in transaction", sql: `REINDEX INDEX CONCURRENTLY movies_title_idx;`, inTransaction: true, want: true},
+		{name: "concurrent reindex without transaction", sql: `REINDEX INDEX CONCURRENTLY movies_title_idx;`, inTransaction: false, want: false},
+		{name: "reindex in transaction", sql: `REINDEX INDEX movies_title_idx;`, inTransaction: true, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := TransactionNotSupportedInConcurrentIndexOperations{}
+			assert.Equal(t, tt.want, r.Process(parseStatement(t, tt.sql), MigrationContext{InTransaction: tt.inTransaction}))
 		})
 	}
 }
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+)
+
+// columnType is a simplified representation of a column data type,
+// consisting of the type name without the schema qualifier, the type modifiers and
+// whether the type is an array.
+type columnType struct {
+	name      string
+	modifiers []int32
+	array     bool
+}
+
+func newColumnType(typeName *pg_query.TypeName) columnType {
+	t := columnType{array: len(typeName.GetArrayBounds()) > 0}
+	if names := typeName.GetNames(); len(names) > 0 {
+		t.name = strings.ToLower(names[len(names)-1].GetString_().GetSval())
+	}
+	for _, m := range typeName.GetTypmods() {
+		t.modifiers = append(t.modifiers, m.GetAConst().GetIval().GetIval())
+	}
+	return t
+}
+
+func (t columnType) String() string {
+	s := t.name
+	if len(t.modifiers) > 0 {
+		var modifiers []string
+		for _, m := range t.modifiers {
+			modifiers = append(modifiers, fmt.Sprintf("%d", m))
+		}
+		s += "(" + strings.Join(modifiers, ",") + ")"
+	}
+	if t.array {
+		s += "[]"
+	}
+	return s
+}
+
+func (t columnType) unconstrained() bool {
+	return len(t.modifiers) == 0
+}
+
+func (t columnType) equal(other columnType) bool {
+	return t.String() == other.String()
+}
+
+// isBinaryCoercibleTo reports whether changing a column from type t to type target
+// is known to not require a table rewrite, according to the binary-compatible casts
+// that PostgreSQL handles as metadata-only changes.
+func (t columnType) isBinaryCoercibleTo(target columnType) bool {
+	if t.equal(target) {
+		return true
+	}
+	if t.array != target.array {
+		return false
+	}
+	switch {
+	case t.name == "varchar" && target.name == "varchar":
+		// Increasing the length limit or removing it altogether
+		return target.unconstrained() || (!t.unconstrained() && target.modifiers[0] >= t.modifiers[0])
+	case t.name == "varchar" && target.name == "text":
+		return true
+	case t.name == "text" && target.name == "varchar":
+		return target.unconstrained()
+	case t.name == "varbit" && target.name == "varbit":
+		return target.unconstrained() || (!t.unconstrained() && target.modifiers[0] >= t.modifiers[0])
+	case t.name == "numeric" && target.name == "numeric":
+		if target.unconstrained() {
+			return true
+		}
+		if t.unconstrained() {
+			return false
+		}
+		// Increasing the precision while keeping the same scale
+		return target.modifiers[0] >= t.modifiers[0] && t.scale() == target.scale()
+	case t.name == "cidr" && target.name == "inet":
+		return true
+	}
+	return false
+}
+
+// mayBeBinaryCoercibleTarget reports whether any column type change to the given type
+// can be performed without a table rewrite. Used when the current column type is unknown.
+func (t columnType) mayBeBinaryCoercibleTarget() bool {
+	switch t.name {
+	case "varchar", "text", "varbit", "numeric", "inet":
+		return true
+	}
+	return false
+}
+
+func (t columnType) scale() int32 {
+	if len(t.modifiers) < 2 {
+		return 0
+	}
+	return t.modifiers[1]
+}
+
+func isSerialType(t columnType) bool {
+	switch t.name {
+	case "smallserial", "serial", "bigserial", "serial2", "serial4", "serial8":
+		return true
+	}
+	return false
+}
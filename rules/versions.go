@@ -0,0 +1,57 @@
+package rules
+
+import "fmt"
+
+// VersionRange is a range of PostgreSQL major versions. Min is inclusive and Max is exclusive,
+// a zero value means that the range is unbounded on that side.
+type VersionRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether the version is in the range. Unknown (zero) versions are treated as the most recent version,
+// so they are only in the ranges without a maximum version.
+func (v VersionRange) Contains(version int) bool {
+	if version == 0 {
+		return v.Max == 0
+	}
+	return (v.Min == 0 || version >= v.Min) && (v.Max == 0 || version < v.Max)
+}
+
+func (v VersionRange) String() string {
+	switch {
+	case v.Min == 0 && v.Max == 0:
+		return "all"
+	case v.Max == 0:
+		return fmt.Sprintf(">=%d", v.Min)
+	case v.Min == 0:
+		return fmt.Sprintf("<%d", v.Max)
+	}
+	return fmt.Sprintf(">=%d <%d", v.Min, v.Max)
+}
+
+// VersionSpecific is implemented by rules that only apply to a range of PostgreSQL versions.
+type VersionSpecific interface {
+	PostgresVersions() VersionRange
+}
+
+// PostgresVersions returns the range of PostgreSQL versions that the rule applies to.
+func PostgresVersions(rule Rule) VersionRange {
+	if versionSpecific, ok := rule.(VersionSpecific); ok {
+		return versionSpecific.PostgresVersions()
+	}
+	return VersionRange{}
+}
+
+// ForVersion returns the rules that apply to the given PostgreSQL major version.
+// When the version is unknown (zero), the rules that only apply to older versions are excluded.
+func (r RuleSet) ForVersion(version int) RuleSet {
+	filtered := NewRuleSet()
+	for _, rule := range r {
+		rule := rule
+		if PostgresVersions(rule).Contains(version) {
+			filtered.Add(rule)
+		}
+	}
+	return filtered
+}
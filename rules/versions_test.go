@@ -0,0 +1,61 @@
+package rules
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestVersionRange_Contains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		r       VersionRange
+		version int
+		want    bool
+	}{
+		{name: "unbounded range", r: VersionRange{}, version: 9, want: true},
+		{name: "unknown version with a minimum version", r: VersionRange{Min: 12}, version: 0, want: true},
+		{name: "unknown version with a maximum version", r: VersionRange{Min: 12, Max: 14}, version: 0, want: false},
+		{name: "minimum version is inclusive", r: VersionRange{Min: 12}, version: 12, want: true},
+		{name: "below minimum version", r: VersionRange{Min: 12}, version: 11, want: false},
+		{name: "maximum version is exclusive", r: VersionRange{Max: 12}, version: 12, want: false},
+		{name: "below maximum version", r: VersionRange{Max: 12}, version: 11, want: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.r.Contains(tt.version))
+		})
+	}
+}
+
+func TestVersionRange_String(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "all", VersionRange{}.String())
+	assert.Equal(t, ">=12", VersionRange{Min: 12}.String())
+	assert.Equal(t, "<12", VersionRange{Max: 12}.String())
+	assert.Equal(t, ">=11 <14", VersionRange{Min: 11, Max: 14}.String())
+}
+
+func TestRuleSet_ForVersion(t *testing.T) {
+	t.Parallel()
+
+	ruleSet := RuleSet{
+		EnumAddValueInTransaction{}.Alias():     EnumAddValueInTransaction{},
+		EnumNewValueUsedInTransaction{}.Alias(): EnumNewValueUsedInTransaction{},
+		DropTable{}.Alias():                     DropTable{},
+	}
+
+	assert.False(t, ruleSet.ForVersion(0).Contains(EnumAddValueInTransaction{}.Alias()))
+	assert.True(t, ruleSet.ForVersion(0).Contains(EnumNewValueUsedInTransaction{}.Alias()))
+	assert.True(t, ruleSet.ForVersion(0).Contains(DropTable{}.Alias()))
+	assert.True(t, ruleSet.ForVersion(11).Contains(EnumAddValueInTransaction{}.Alias()))
+	assert.False(t, ruleSet.ForVersion(11).Contains(EnumNewValueUsedInTransaction{}.Alias()))
+	assert.False(t, ruleSet.ForVersion(16).Contains(EnumAddValueInTransaction{}.Alias()))
+	assert.True(t, ruleSet.ForVersion(16).Contains(EnumNewValueUsedInTransaction{}.Alias()))
+	assert.True(t, ruleSet.ForVersion(16).Contains(DropTable{}.Alias()))
+}
@@ -0,0 +1,82 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"pgsafemigrate/catalog"
+)
+
+// RefreshMaterializedViewNonConcurrently - Refreshing a materialized view blocks reads while the view is being refreshed.
+type RefreshMaterializedViewNonConcurrently struct {
+	NewTableExemption `yaml:",inline"`
+}
+
+func (r RefreshMaterializedViewNonConcurrently) Alias() string {
+	return HighAvailabilityRule("avoid-non-concurrent-materialized-view-refresh")
+}
+
+func (r RefreshMaterializedViewNonConcurrently) Documentation() string {
+	return "Non-concurrent materialized view refresh acquires an ACCESS EXCLUSIVE lock that blocks all reads of the view while it is being refreshed. " +
+		"Use REFRESH MATERIALIZED VIEW CONCURRENTLY, which requires a unique index on the view."
+}
+
+func (r RefreshMaterializedViewNonConcurrently) Configure(unmarshal func(any) error) (Rule, error) {
+	if err := unmarshal(&r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r RefreshMaterializedViewNonConcurrently) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	if r.exempt(node, ctx) {
+		return false
+	}
+	refreshStmt := node.GetRefreshMatViewStmt()
+	if refreshStmt == nil {
+		return false
+	}
+	return !refreshStmt.GetConcurrent()
+}
+
+// ConcurrentRefreshRequiresUniqueIndex - Concurrent materialized view refresh fails unless the view has a unique index.
+type ConcurrentRefreshRequiresUniqueIndex struct{}
+
+func (r ConcurrentRefreshRequiresUniqueIndex) Alias() string {
+	return HighAvailabilityRule("concurrent-refresh-requires-unique-index")
+}
+
+func (r ConcurrentRefreshRequiresUniqueIndex) Documentation() string {
+	return "Concurrent materialized view refresh requires a unique index on the view that uses only column names and includes all rows. " +
+		"Only reported when the materialized view has been created in a known migration."
+}
+
+func (r ConcurrentRefreshRequiresUniqueIndex) Process(node *pg_query.Node, ctx MigrationContext) bool {
+	refreshStmt := node.GetRefreshMatViewStmt()
+	if refreshStmt == nil || !refreshStmt.GetConcurrent() {
+		return false
+	}
+	viewName := refreshStmt.GetRelation().GetRelname()
+	cat := ctx.CatalogBefore(node)
+	if view, ok := cat.View(viewName); !ok || !view.Materialized {
+		return false
+	}
+	for _, index := range cat.Indexes(viewName) {
+		if isRefreshCompatibleUniqueIndex(index) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRefreshCompatibleUniqueIndex reports whether the index can be used by a concurrent materialized view refresh,
+// i.e. it is a unique, non-partial index on column names only.
+func isRefreshCompatibleUniqueIndex(index *catalog.Index) bool {
+	if !index.Unique || index.Partial {
+		return false
+	}
+	for _, column := range index.Columns {
+		if column == "" {
+			return false
+		}
+	}
+	return true
+}
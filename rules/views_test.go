@@ -0,0 +1,98 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRefreshMaterializedViewNonConcurrently_Process(t *testing.T) {
+	t.Parallel()
+
+	r := RefreshMaterializedViewNonConcurrently{}
+	assert.True(t, r.Process(parseStatement(t, `REFRESH MATERIALIZED VIEW movie_ratings;`), MigrationContext{}))
+	assert.False(t, r.Process(parseStatement(t, `REFRESH MATERIALIZED VIEW CONCURRENTLY movie_ratings;`), MigrationContext{}))
+	assert.False(t, r.Process(parseStatement(t, `SELECT * FROM movie_ratings;`), MigrationContext{}))
+}
+
+func TestConcurrentRefreshRequiresUniqueIndex_Process(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		history string
+		sql     string
+		want    bool
+	}{
+		{
+			name: "materialized view is unknown",
+			sql:  `REFRESH MATERIALIZED VIEW CONCURRENTLY movie_ratings;`,
+			want: false,
+		},
+		{
+			name:    "materialized view without unique index",
+			history: `CREATE MATERIALIZED VIEW movie_ratings AS SELECT movie_id, avg(rating) FROM ratings GROUP BY movie_id;`,
+			sql:     `REFRESH MATERIALIZED VIEW CONCURRENTLY movie_ratings;`,
+			want:    true,
+		},
+		{
+			name: "materialized view with unique index in previous migration",
+			history: `CREATE MATERIALIZED VIEW movie_ratings AS SELECT movie_id, avg(rating) FROM ratings GROUP BY movie_id;
+CREATE UNIQUE INDEX movie_ratings_movie_id_idx ON movie_ratings (movie_id);`,
+			sql:  `REFRESH MATERIALIZED VIEW CONCURRENTLY movie_ratings;`,
+			want: false,
+		},
+		{
+			name: "materialized view with unique index in the same migration",
+			sql: `CREATE MATERIALIZED VIEW movie_ratings AS SELECT movie_id, avg(rating) FROM ratings GROUP BY movie_id;
+CREATE UNIQUE INDEX ON movie_ratings (movie_id);
+REFRESH MATERIALIZED VIEW CONCURRENTLY movie_ratings;`,
+			want: false,
+		},
+		{
+			name: "materialized view with partial unique index",
+			sql: `CREATE MATERIALIZED VIEW movie_ratings AS SELECT movie_id, avg(rating) FROM ratings GROUP BY movie_id;
+CREATE UNIQUE INDEX movie_ratings_movie_id_idx ON movie_ratings (movie_id) WHERE movie_id > 0;
+REFRESH MATERIALIZED VIEW CONCURRENTLY movie_ratings;`,
+			want: true,
+		},
+		{
+			name: "materialized view with dropped unique index",
+			history: `CREATE MATERIALIZED VIEW movie_ratings AS SELECT movie_id, avg(rating) FROM ratings GROUP BY movie_id;
+CREATE UNIQUE INDEX movie_ratings_movie_id_idx ON movie_ratings (movie_id);`,
+			sql: `DROP INDEX movie_ratings_movie_id_idx;
+REFRESH MATERIALIZED VIEW CONCURRENTLY movie_ratings;`,
+			want: true,
+		},
+		{
+			name:    "non-concurrent refresh",
+			history: `CREATE MATERIALIZED VIEW movie_ratings AS SELECT movie_id, avg(rating) FROM ratings GROUP BY movie_id;`,
+			sql:     `REFRESH MATERIALIZED VIEW movie_ratings;`,
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parse := func(sql string) []*pg_query.Node {
+				var nodes []*pg_query.Node
+				if sql == "" {
+					return nodes
+				}
+				for _, statement := range strings.Split(sql, "\n") {
+					nodes = append(nodes, parseStatement(t, statement))
+				}
+				return nodes
+			}
+			allNodes := parse(tt.sql)
+			r := ConcurrentRefreshRequiresUniqueIndex{}
+			assert.Equal(t, tt.want, r.Process(allNodes[len(allNodes)-1], MigrationContext{
+				AllStatements: allNodes,
+				Catalog:       newCatalog(parse(tt.history)),
+			}))
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package rules
+
+import (
+	pg_query "github.com/pganalyze/pg_query_go/v4"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// walk traverses the parse tree of the given message depth-first and calls visit for each node found.
+// The traversal does not descend into the children of a node when visit returns false.
+func walk(message proto.Message, visit func(node *pg_query.Node) bool) {
+	if message == nil {
+		return
+	}
+	m := message.ProtoReflect()
+	if !m.IsValid() {
+		return
+	}
+	if node, ok := message.(*pg_query.Node); ok {
+		if !visit(node) {
+			return
+		}
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind {
+			return true
+		}
+		if fd.IsList() {
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				walk(list.Get(i).Message().Interface(), visit)
+			}
+			return true
+		}
+		if !fd.IsMap() {
+			walk(v.Message().Interface(), visit)
+		}
+		return true
+	})
+}